@@ -108,6 +108,12 @@ func main() {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
 	defer cancel()
 
+	// Hijacked websocket connections aren't tracked by server.Shutdown, so the container closes
+	// them itself along with the background workers
+	if err := appContainer.Shutdown(ctx); err != nil {
+		logger.Error("background services forced to stop", "error", err)
+	}
+
 	// shutdown server
 	if err := server.Shutdown(ctx); err != nil {
 		logger.Error("server forced to shutdown", "error", err)
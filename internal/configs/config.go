@@ -3,7 +3,10 @@ package config
 import (
 	"fmt"
 	"os"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 )
 
 type Config struct {
@@ -21,6 +24,8 @@ type Config struct {
 	PaystackSecretKey     string
 	PaystackTestPublicKey string
 	PaystackTestSecretKey string
+	PaystackBaseURL       string
+	PaystackCurrency      string
 	ResendAPIKey          string
 	// Supabase Configuration
 	SupbaseUrl         string
@@ -32,6 +37,14 @@ type Config struct {
 	SupabaseJWTSecret    string
 	JWTAccessExpiration  string // e.g., "15m", "1h"
 	JWTRefreshExpiration string // e.g., "7d", "30d"
+	// Auction Configuration
+	BuyNowAfterReserve bool   // allow Buy It Now once bidding has met the reserve
+	PaymentDeadline    string // e.g., "48h"
+	PaymentReminders   string // comma separated time before the deadline, e.g., "24h,1h"
+	EndingNotices      string // comma separated time before an auction ends, e.g., "1h,10m,1m"
+	// Worker Configuration
+	WorkerLease    string // "postgres" to elect one worker across instances, "memory" for a single instance
+	WorkerLeaseTTL string // e.g., "30s"
 }
 
 func LoadConfig() (*Config, error) {
@@ -55,11 +68,21 @@ func LoadConfig() (*Config, error) {
 		PaystackSecretKey:     os.Getenv("PAYSTACK_SECRET_KEY"),
 		PaystackTestPublicKey: os.Getenv("PAYSTACK_TEST_PUBLIC_KEY"),
 		PaystackTestSecretKey: os.Getenv("PAYSTACK_TEST_SECRET_KEY"),
+		PaystackBaseURL:       getEnvWithDefault("PAYSTACK_BASE_URL", "https://api.paystack.co"),
+		PaystackCurrency:      getEnvWithDefault("PAYSTACK_CURRENCY", "GHS"),
 		// JWT Configuration
 		JWTSecret:            os.Getenv("JWT_SECRET"),
 		SupabaseJWTSecret:    os.Getenv("SUPABASE_JWT_SECRET"),
 		JWTAccessExpiration:  getEnvWithDefault("JWT_ACCESS_EXPIRATION", "15m"),
 		JWTRefreshExpiration: getEnvWithDefault("JWT_REFRESH_EXPIRATION", "7d"),
+		// Auction Configuration
+		BuyNowAfterReserve: getEnvBool("BUY_NOW_AFTER_RESERVE", false),
+		PaymentDeadline:    getEnvWithDefault("PAYMENT_DEADLINE", "48h"),
+		PaymentReminders:   getEnvWithDefault("PAYMENT_REMINDERS", "24h,6h,1h"),
+		EndingNotices:      getEnvWithDefault("AUCTION_ENDING_NOTICES", "1h,10m,1m"),
+		// Worker Configuration
+		WorkerLease:    strings.ToLower(strings.TrimSpace(getEnvWithDefault("WORKER_LEASE", "memory"))),
+		WorkerLeaseTTL: getEnvWithDefault("WORKER_LEASE_TTL", "30s"),
 	}
 
 	allowedOrigins := strings.TrimSpace(os.Getenv("ALLOWED_ORIGINS"))
@@ -130,6 +153,12 @@ func LoadConfig() (*Config, error) {
 		}
 	}
 
+	// A typo here would quietly give every instance its own in-memory lease, and they would
+	// all run the worker
+	if cfg.WorkerLease != "memory" && cfg.WorkerLease != "postgres" {
+		return nil, fmt.Errorf("WORKER_LEASE must be memory or postgres, got %q", cfg.WorkerLease)
+	}
+
 	// if
 	return cfg, nil
 }
@@ -141,6 +170,14 @@ func getEnvWithDefault(key, defaultValue string) string {
 	return defaultValue
 }
 
+func getEnvBool(key string, defaultValue bool) bool {
+	value, err := strconv.ParseBool(strings.TrimSpace(os.Getenv(key)))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
 func (c *Config) IsProduction() bool {
 	return c.Environment == "production"
 }
@@ -149,6 +186,48 @@ func (c *Config) IsDevelopment() bool {
 	return c.Environment == "development"
 }
 
+// GetPaystackSecretKey returns the live key in production and the test key everywhere else
+func (c *Config) GetPaystackSecretKey() string {
+	if c.IsProduction() {
+		return c.PaystackSecretKey
+	}
+	return c.PaystackTestSecretKey
+}
+
+// GetPaymentDeadline parses PaymentDeadline, falling back to 48h when it is missing or invalid
+func (c *Config) GetPaymentDeadline() time.Duration {
+	d, err := time.ParseDuration(c.PaymentDeadline)
+	if err != nil || d <= 0 {
+		return 48 * time.Hour
+	}
+	return d
+}
+
+// GetPaymentReminders parses PaymentReminders into offsets before the deadline, longest first.
+// Invalid entries and offsets beyond the deadline are dropped
+func (c *Config) GetPaymentReminders() []time.Duration {
+	deadline := c.GetPaymentDeadline()
+	reminders := make([]time.Duration, 0)
+	for _, part := range splitAndTrim(c.PaymentReminders) {
+		d, err := time.ParseDuration(part)
+		if err != nil || d <= 0 || d >= deadline {
+			continue
+		}
+		reminders = append(reminders, d)
+	}
+	sort.Slice(reminders, func(i, j int) bool { return reminders[i] > reminders[j] })
+	return reminders
+}
+
+// GetWorkerLeaseTTL parses WorkerLeaseTTL, falling back to 30s when it is missing or invalid
+func (c *Config) GetWorkerLeaseTTL() time.Duration {
+	d, err := time.ParseDuration(c.WorkerLeaseTTL)
+	if err != nil || d < 3*time.Second {
+		return 30 * time.Second
+	}
+	return d
+}
+
 // GetCloudinaryURL builds the Cloudinary URL from config fields
 func (c *Config) GetCloudinaryURL() string {
 	if c.CloudinaryCloudName == "" || c.CloudinaryAPIKey == "" || c.CloudinaryAPISecret == "" {
@@ -168,3 +247,18 @@ func splitAndTrim(input string) []string {
 	}
 	return result
 }
+
+// GetEndingNotices parses EndingNotices into offsets before an auction's end, longest first.
+// Invalid entries are dropped
+func (c *Config) GetEndingNotices() []time.Duration {
+	notices := make([]time.Duration, 0)
+	for _, part := range splitAndTrim(c.EndingNotices) {
+		d, err := time.ParseDuration(part)
+		if err != nil || d <= 0 {
+			continue
+		}
+		notices = append(notices, d)
+	}
+	sort.Slice(notices, func(i, j int) bool { return notices[i] > notices[j] })
+	return notices
+}
@@ -24,6 +24,12 @@ const (
 	AuctionCancelled = "CANCELLED"
 )
 
+const (
+	PaymentPending = "PENDING"
+	PaymentSuccess = "SUCCESS"
+	PaymentFailed  = "FAILED"
+)
+
 // Common error messages
 var (
 	ErrInvalidInput            = errors.New("invalid user input")
@@ -51,6 +57,25 @@ var (
 	ErrAuctionNotLive          = errors.New("auction is not live")
 	ErrBidOnOwnAuction         = errors.New("cannot bid on your own auction")
 	ErrAuctionEnded            = errors.New("auction has already ended")
+	ErrMaxBidTooLow            = errors.New("maximum bid cannot be lower than the bid amount")
+	ErrBuyNowUnavailable       = errors.New("buy now is not available for this auction")
+	ErrReserveMet              = errors.New("buy now is closed once bidding has met the reserve")
+	ErrBuyOwnAuction           = errors.New("cannot buy your own auction")
+	ErrNotWinner               = errors.New("only the winning bidder can pay for this auction")
+	ErrAuctionNotPayable       = errors.New("auction is not awaiting payment")
+	ErrPaymentMismatch         = errors.New("payment does not match the auction")
+	ErrPaymentDeadlinePassed   = errors.New("payment deadline has passed")
+	ErrAuctionNotEditable      = errors.New("auction can no longer be edited")
+	ErrAuctionNotCancellable   = errors.New("auction can no longer be cancelled")
+	ErrCancelReasonRequired    = errors.New("a reason is required to cancel an auction")
+	ErrInvalidTransition       = errors.New("invalid auction status transition")
+	ErrSealedBidMaxAmount      = errors.New("maximum bids are not available on sealed-bid auctions")
+	ErrNotBiddable             = errors.New("this auction takes accepts rather than bids")
+	ErrNotDutchAuction         = errors.New("only dutch auctions can be accepted")
+	ErrAcceptOwnAuction        = errors.New("cannot accept your own auction")
+	ErrInvalidBidQuantity      = errors.New("bid quantity must be between 1 and the number of units on offer")
+	ErrBidTooHigh              = errors.New("bid must undercut the current best bid")
+	ErrLotBidReduced           = errors.New("a lot bid can't lower its quantity or unit price")
 )
 
 // Success messages
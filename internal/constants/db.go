@@ -3,10 +3,17 @@ package constants
 type DbConstants string
 
 const (
-	DbName       DbConstants = "auction"
-	ProductTable DbConstants = "products"
-	UserTable    DbConstants = "users"
-	ProfileTable DbConstants = "profiles"
-	AuctionTable DbConstants = "auctions"
-	BidTable     DbConstants = "bids"
+	DbName                 DbConstants = "auction"
+	ProductTable           DbConstants = "products"
+	UserTable              DbConstants = "users"
+	ProfileTable           DbConstants = "profiles"
+	AuctionTable           DbConstants = "auctions"
+	BidTable               DbConstants = "bids"
+	MaxBidTable            DbConstants = "max_bids"
+	PaymentTable           DbConstants = "payments"
+	AuctionTransitionTable DbConstants = "auction_transitions"
+	IncrementTableTable    DbConstants = "increment_tables"
+	SealedBidTable         DbConstants = "sealed_bids"
+	LotBidTable            DbConstants = "lot_bids"
+	LotAllocationTable     DbConstants = "lot_allocations"
 )
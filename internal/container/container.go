@@ -1,6 +1,7 @@
 package container
 
 import (
+	"context"
 	"log/slog"
 	"time"
 
@@ -8,6 +9,7 @@ import (
 	config "github.com/joshua-takyi/auction/internal/configs"
 	"github.com/joshua-takyi/auction/internal/jwt"
 	"github.com/joshua-takyi/auction/internal/models"
+	"github.com/joshua-takyi/auction/internal/payments"
 	"github.com/joshua-takyi/auction/internal/service"
 	"github.com/joshua-takyi/auction/internal/websockets"
 	"github.com/resend/resend-go/v3"
@@ -28,10 +30,19 @@ type Container struct {
 	UserService         *service.UserService
 	ProductService      *service.ProductService
 	AuctionService      *service.AuctionService
+	AuctionStateMachine *service.AuctionStateMachine
+	AuctionScheduler    *service.AuctionScheduler
+	IncrementService    *service.IncrementService
 	JWTManager          *jwt.JWTManager
 	WSManager           *websockets.Manager
 	NotificationService *service.NotificationService
 	BidService          *service.BidService
+	SettlementService   *service.SettlementService
+	PaymentService      *service.PaymentService
+	DutchService        *service.DutchService
+	RelistService       *service.RelistService
+	NoticeService       *service.AuctionNoticeService
+	WorkerElector       *service.LeaderElector
 	WorkerService       *service.WorkerService
 }
 
@@ -49,7 +60,6 @@ func NewContainer(
 
 	userService := service.NewUserService(supaRepo, resendClient)
 	productService := service.NewProductService(supaRepo, cloudinary)
-	auctionService := service.NewAuctionService(supaRepo, supaRepo)
 	accessDuration, err := time.ParseDuration(cfg.JWTAccessExpiration)
 
 	//TODO: remove this
@@ -64,9 +74,42 @@ func NewContainer(
 	wsManager.Start()
 
 	notificationService := service.NewNotificationService(wsManager)
-	bidService := service.NewBidService(supaRepo, supaRepo, jwtManager, notificationService)
+	stateMachine := service.NewAuctionStateMachine(supaRepo, logger)
 
-	workerService := service.NewWorkerService(auctionService, logger)
+	// Every instance runs the worker and the scheduler, but only the lease holder does the work
+	var leases models.LeaseInterface = supaRepo
+	if cfg.WorkerLease == "memory" {
+		leases = models.NewMemoryLeases()
+	}
+	workerElector := service.NewLeaderElector(leases, "auction-worker", cfg.GetWorkerLeaseTTL(), logger)
+	workerElector.Start()
+
+	scheduler := service.NewAuctionScheduler(supaRepo, stateMachine, workerElector, logger)
+	incrementService := service.NewIncrementService(supaRepo)
+	endingNotices := cfg.GetEndingNotices()
+	auctionService := service.NewAuctionService(supaRepo, supaRepo, supaRepo, supaRepo, supaRepo, stateMachine, scheduler, incrementService, notificationService, cfg.BuyNowAfterReserve, endingNotices)
+	bidService := service.NewBidService(supaRepo, supaRepo, supaRepo, supaRepo, supaRepo, scheduler, incrementService, jwtManager, notificationService, endingNotices)
+
+	settlementService := service.NewSettlementService(supaRepo, supaRepo, supaRepo, supaRepo, supaRepo, stateMachine, notificationService, logger, cfg.GetPaymentDeadline(), cfg.GetPaymentReminders())
+
+	paystackClient := payments.NewPaystackClient(cfg.GetPaystackSecretKey(), cfg.PaystackBaseURL)
+	paymentService := service.NewPaymentService(supaRepo, supaRepo, stateMachine, paystackClient, cfg.PaystackCurrency, cfg.FrontendURL+"/payments/callback")
+
+	dutchService := service.NewDutchService(supaRepo, supaRepo, stateMachine, notificationService, logger)
+	if err := dutchService.Start(context.Background()); err != nil {
+		logger.Error("Failed to start dutch auction clocks", "error", err)
+	}
+
+	relistService := service.NewRelistService(supaRepo, auctionService, notificationService, logger)
+
+	noticeService := service.NewAuctionNoticeService(supaRepo, supaRepo, supaRepo, supaRepo, stateMachine, scheduler, notificationService, logger, endingNotices)
+
+	// Starts and ends land on the second; the worker's sweep below is only a safety net
+	if err := scheduler.Start(context.Background()); err != nil {
+		logger.Error("Failed to load auction schedule", "error", err)
+	}
+
+	workerService := service.NewWorkerService(auctionService, settlementService, relistService, noticeService, workerElector, logger)
 	// Start the worker (e.g. every 2 minutes as requested)
 	workerService.Start(2 * time.Minute)
 
@@ -81,10 +124,42 @@ func NewContainer(
 		UserService:           userService,
 		ProductService:        productService,
 		AuctionService:        auctionService,
+		AuctionStateMachine:   stateMachine,
+		AuctionScheduler:      scheduler,
+		IncrementService:      incrementService,
 		JWTManager:            jwtManager,
 		WSManager:             wsManager,
 		NotificationService:   notificationService,
 		BidService:            bidService,
+		SettlementService:     settlementService,
+		PaymentService:        paymentService,
+		DutchService:          dutchService,
+		RelistService:         relistService,
+		NoticeService:         noticeService,
+		WorkerElector:         workerElector,
 		WorkerService:         workerService,
 	}, nil
 }
+
+// Shutdown stops the websocket manager and the background jobs within ctx's deadline. The
+// manager goes first so no new connections arrive while the rest wind down. It returns the
+// first error but always tries every step
+func (c *Container) Shutdown(ctx context.Context) error {
+	var firstErr error
+	if err := c.WSManager.Stop(ctx); err != nil {
+		c.Logger.Error("Websocket connections did not close in time", "error", err)
+		firstErr = err
+	}
+
+	if err := c.WorkerService.Stop(ctx); err != nil {
+		c.Logger.Error("Worker run cancelled before it finished", "error", err)
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	// After the worker and the scheduler so neither loses its lease partway through a run
+	c.AuctionScheduler.Stop()
+	c.WorkerElector.Stop()
+	c.DutchService.Stop()
+	return firstErr
+}
@@ -64,6 +64,118 @@ func CreateAuctionHandler(s *service.AuctionService) gin.HandlerFunc {
 	}
 }
 
+func UpdateAuctionHandler(s *service.AuctionService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		auctionParamId := c.Param(strings.TrimSpace("id"))
+		if auctionParamId == "" {
+			utils.BadRequest(c, "auction id can't be empty", auctionParamId)
+			return
+		}
+
+		auctionID, err := uuid.Parse(auctionParamId)
+		if err != nil {
+			utils.BadRequest(c, "invalid auction id", "auction_id")
+			return
+		}
+
+		user, exists := c.Get("user")
+		if !exists {
+			utils.Unauthorized(c, "user not authenticated", "user")
+			return
+		}
+
+		claims, ok := user.(*models.User)
+		if !ok {
+			utils.Unauthorized(c, "invalid user token", "user")
+			return
+		}
+
+		var update models.AuctionUpdate
+		if err := c.ShouldBindJSON(&update); err != nil {
+			utils.BadRequest(c, "invalid auction data", "auction")
+			return
+		}
+
+		accessToken, _ := c.Cookie("access_token")
+
+		updated, err := s.UpdateAuction(c.Request.Context(), auctionID, update, claims, accessToken)
+		if err != nil {
+			switch {
+			case errors.Is(err, constants.ErrNotFound), errors.Is(err, constants.ErrNoData):
+				utils.NotFound(c, "no data found matching the id", "auction")
+			case errors.Is(err, constants.ErrForbidden):
+				utils.Forbidden(c, "only the owner or an admin can edit this auction", "user")
+			case errors.Is(err, constants.ErrAuctionNotEditable):
+				utils.Conflict(c, err.Error(), "auction")
+			case errors.Is(err, constants.ErrInvalidInput):
+				utils.BadRequest(c, err.Error(), "auction")
+			default:
+				utils.InternalServerError(c, "failed to update auction", err.Error())
+			}
+			return
+		}
+
+		utils.OK(c, "auction updated successfully", updated)
+	}
+}
+
+type CancelAuctionRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+func CancelAuctionHandler(s *service.AuctionService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		auctionParamId := c.Param(strings.TrimSpace("id"))
+		if auctionParamId == "" {
+			utils.BadRequest(c, "auction id can't be empty", auctionParamId)
+			return
+		}
+
+		auctionID, err := uuid.Parse(auctionParamId)
+		if err != nil {
+			utils.BadRequest(c, "invalid auction id", "auction_id")
+			return
+		}
+
+		user, exists := c.Get("user")
+		if !exists {
+			utils.Unauthorized(c, "user not authenticated", "user")
+			return
+		}
+
+		claims, ok := user.(*models.User)
+		if !ok {
+			utils.Unauthorized(c, "invalid user token", "user")
+			return
+		}
+
+		var req CancelAuctionRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.BadRequest(c, constants.ErrCancelReasonRequired.Error(), "reason")
+			return
+		}
+
+		cancelled, err := s.CancelAuction(c.Request.Context(), auctionID, req.Reason, claims)
+		if err != nil {
+			switch {
+			case errors.Is(err, constants.ErrNotFound):
+				utils.NotFound(c, "no data found matching the id", "auction")
+			case errors.Is(err, constants.ErrForbidden):
+				utils.Forbidden(c, err.Error(), "user")
+			case errors.Is(err, constants.ErrAuctionNotCancellable):
+				utils.Conflict(c, err.Error(), "auction")
+			case errors.Is(err, constants.ErrCancelReasonRequired):
+				utils.BadRequest(c, err.Error(), "reason")
+			default:
+				utils.InternalServerError(c, "failed to cancel auction", err.Error())
+			}
+			return
+		}
+
+		utils.OK(c, "auction cancelled successfully", cancelled)
+	}
+}
+
 func DeleteAuctionHandler(s *service.AuctionService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		auctionParamId := c.Param(strings.TrimSpace("id"))
@@ -111,6 +223,17 @@ func DeleteAuctionHandler(s *service.AuctionService) gin.HandlerFunc {
 	}
 }
 
+// optionalViewer returns the signed-in user on routes behind the optional auth middleware, or nil
+// for guests
+func optionalViewer(c *gin.Context) *models.User {
+	user, exists := c.Get("user")
+	if !exists {
+		return nil
+	}
+	viewer, _ := user.(*models.User)
+	return viewer
+}
+
 func GetAuctionByIdHandler(s *service.AuctionService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		auctionParamId := c.Param(strings.TrimSpace("id"))
@@ -124,7 +247,8 @@ func GetAuctionByIdHandler(s *service.AuctionService) gin.HandlerFunc {
 			utils.BadRequest(c, "invalid auction id", "auction_id")
 			return
 		}
-		returnedAuction, err := s.GetAuctionById(c.Request.Context(), parsedAuctionId)
+
+		returnedAuction, err := s.GetAuctionForViewer(c.Request.Context(), parsedAuctionId, optionalViewer(c))
 
 		if err != nil {
 			switch {
@@ -155,7 +279,7 @@ func ListAuctionsHandler(s *service.AuctionService) gin.HandlerFunc {
 		params.Validate()
 
 		// 2. Update service to return auctions AND total count
-		auctions, total, err := s.ListAuctions(c.Request.Context(), params.GetLimit(), params.GetOffset())
+		auctions, total, err := s.ListAuctions(c.Request.Context(), params.GetLimit(), params.GetOffset(), optionalViewer(c))
 
 		if err != nil {
 			if errors.Is(err, constants.ErrNoData) {
@@ -187,7 +311,7 @@ func SearchAuctionsHandler(s *service.AuctionService) gin.HandlerFunc {
 		}
 		params.Validate()
 
-		auctions, total, err := s.SearchAuctions(c.Request.Context(), query, params.GetLimit(), params.GetOffset())
+		auctions, total, err := s.SearchAuctions(c.Request.Context(), query, params.GetLimit(), params.GetOffset(), optionalViewer(c))
 
 		if err != nil {
 			if errors.Is(err, constants.ErrNoData) {
@@ -217,7 +341,7 @@ func FilterAuctionsHandler(s *service.AuctionService) gin.HandlerFunc {
 		}
 		params.Validate()
 
-		auctions, total, err := s.FilterAuctions(c.Request.Context(), filter, params.GetLimit(), params.GetOffset())
+		auctions, total, err := s.FilterAuctions(c.Request.Context(), filter, params.GetLimit(), params.GetOffset(), optionalViewer(c))
 
 		if err != nil {
 			if errors.Is(err, constants.ErrNoData) {
@@ -247,7 +371,7 @@ func RecommendationHandler(s *service.AuctionService) gin.HandlerFunc {
 			params = utils.DefaultPaginationParams()
 		}
 
-		auctions, total, err := s.Recommendation(c.Request.Context(), categoryParam, currentID, params.GetLimit(), params.GetOffset())
+		auctions, total, err := s.Recommendation(c.Request.Context(), categoryParam, currentID, params.GetLimit(), params.GetOffset(), optionalViewer(c))
 		if err != nil {
 			if errors.Is(err, constants.ErrNoData) {
 				utils.PaginatedOK(c, "no auctions found", []any{}, utils.NewPaginationMeta(params.Page, params.PageSize, 0))
@@ -305,3 +429,124 @@ func GetUserAuctions(service *service.AuctionService) gin.HandlerFunc {
 		utils.PaginatedOK(c, "user auctions retrieved successfully", res, meta)
 	}
 }
+
+func BuyNowHandler(s *service.AuctionService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		auctionParamId := c.Param(strings.TrimSpace("id"))
+		if auctionParamId == "" {
+			utils.BadRequest(c, "auction id can't be empty", auctionParamId)
+			return
+		}
+
+		auctionID, err := uuid.Parse(auctionParamId)
+		if err != nil {
+			utils.BadRequest(c, "invalid auction id", "auction_id")
+			return
+		}
+
+		user, exists := c.Get("user")
+		if !exists {
+			utils.Unauthorized(c, "user not authenticated", "user")
+			return
+		}
+
+		claims, ok := user.(*models.User)
+		if !ok {
+			utils.Unauthorized(c, "invalid user token", "user")
+			return
+		}
+
+		bought, err := s.BuyNow(c.Request.Context(), auctionID, claims.ID)
+		if err != nil {
+			switch {
+			case errors.Is(err, constants.ErrNotFound):
+				utils.NotFound(c, "no data found matching the id", "auction")
+			case errors.Is(err, constants.ErrBuyOwnAuction):
+				utils.Forbidden(c, err.Error(), "auction")
+			case errors.Is(err, constants.ErrBuyNowUnavailable),
+				errors.Is(err, constants.ErrReserveMet),
+				errors.Is(err, constants.ErrAuctionNotLive),
+				errors.Is(err, constants.ErrAuctionEnded):
+				utils.Conflict(c, err.Error(), "auction")
+			default:
+				utils.InternalServerError(c, "failed to complete purchase", err.Error())
+			}
+			return
+		}
+
+		utils.OK(c, "auction purchased successfully", bought)
+	}
+}
+
+func AcceptDutchAuctionHandler(s *service.DutchService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		auctionParamId := c.Param(strings.TrimSpace("id"))
+		if auctionParamId == "" {
+			utils.BadRequest(c, "auction id can't be empty", auctionParamId)
+			return
+		}
+
+		auctionID, err := uuid.Parse(auctionParamId)
+		if err != nil {
+			utils.BadRequest(c, "invalid auction id", "auction_id")
+			return
+		}
+
+		user, exists := c.Get("user")
+		if !exists {
+			utils.Unauthorized(c, "user not authenticated", "user")
+			return
+		}
+
+		claims, ok := user.(*models.User)
+		if !ok {
+			utils.Unauthorized(c, "invalid user token", "user")
+			return
+		}
+
+		accepted, err := s.Accept(c.Request.Context(), auctionID, claims.ID)
+		if err != nil {
+			switch {
+			case errors.Is(err, constants.ErrNotFound):
+				utils.NotFound(c, "no data found matching the id", "auction")
+			case errors.Is(err, constants.ErrAcceptOwnAuction):
+				utils.Forbidden(c, err.Error(), "auction")
+			case errors.Is(err, constants.ErrNotDutchAuction):
+				utils.BadRequest(c, err.Error(), "auction")
+			case errors.Is(err, constants.ErrAuctionNotLive),
+				errors.Is(err, constants.ErrAuctionEnded):
+				utils.Conflict(c, err.Error(), "auction")
+			default:
+				utils.InternalServerError(c, "failed to accept auction", err.Error())
+			}
+			return
+		}
+
+		utils.OK(c, "auction accepted successfully", accepted)
+	}
+}
+
+func GetLotAllocationsHandler(s *service.AuctionService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		auctionID, err := uuid.Parse(strings.TrimSpace(c.Param("id")))
+		if err != nil {
+			utils.BadRequest(c, "invalid auction id", "auction_id")
+			return
+		}
+
+		allocations, err := s.GetLotAllocations(c.Request.Context(), auctionID)
+		if err != nil {
+			switch {
+			case errors.Is(err, constants.ErrNotFound):
+				utils.NotFound(c, "no data found matching the id", "auction")
+			case errors.Is(err, constants.ErrInvalidInput), errors.Is(err, constants.ErrInvalidID):
+				utils.BadRequest(c, err.Error(), "auction")
+			default:
+				utils.InternalServerError(c, "internal server error", "server error")
+			}
+			return
+		}
+
+		utils.OK(c, "allocations retrieved successfully", allocations)
+	}
+}
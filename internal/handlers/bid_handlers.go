@@ -15,7 +15,9 @@ import (
 )
 
 type BidRequest struct {
-	Amount decimal.Decimal `json:"amount" validate:"required"`
+	Amount    decimal.Decimal  `json:"amount" validate:"required"`
+	MaxAmount *decimal.Decimal `json:"max_amount"`
+	Quantity  int              `json:"quantity"` // units wanted on a multi-unit auction; Amount is then the unit price
 }
 
 func PlaceBidHandler(bidService *service.BidService) gin.HandlerFunc {
@@ -47,7 +49,7 @@ func PlaceBidHandler(bidService *service.BidService) gin.HandlerFunc {
 			return
 		}
 
-		result, err := bidService.PlaceBid(ctx, req.Amount, auctionID, accessToken)
+		result, err := bidService.PlaceBid(ctx, req.Amount, req.MaxAmount, req.Quantity, auctionID, accessToken)
 		if err != nil {
 			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
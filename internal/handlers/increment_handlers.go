@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/joshua-takyi/auction/internal/constants"
+	"github.com/joshua-takyi/auction/internal/models"
+	"github.com/joshua-takyi/auction/internal/service"
+	"github.com/joshua-takyi/auction/internal/utils"
+)
+
+func CreateIncrementTableHandler(s *service.IncrementService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, exists := c.Get("user")
+		if !exists {
+			utils.Unauthorized(c, "user not authenticated", "user")
+			return
+		}
+
+		claims, ok := user.(*models.User)
+		if !ok {
+			utils.Unauthorized(c, "invalid user token", "user")
+			return
+		}
+
+		if !claims.IsAdmin() {
+			utils.Forbidden(c, "only admins can manage increment tables", "user")
+			return
+		}
+
+		var table models.IncrementTable
+		if err := c.ShouldBindJSON(&table); err != nil {
+			utils.BadRequest(c, "invalid increment table data", "increment_table")
+			return
+		}
+
+		created, err := s.CreateIncrementTable(c.Request.Context(), &table)
+		if err != nil {
+			switch {
+			case errors.Is(err, constants.ErrInvalidInput):
+				utils.BadRequest(c, err.Error(), "increment_table")
+			default:
+				utils.InternalServerError(c, "failed to create increment table", err.Error())
+			}
+			return
+		}
+
+		utils.Created(c, "increment table created successfully", created)
+	}
+}
+
+func ListIncrementTablesHandler(s *service.IncrementService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tables, err := s.ListIncrementTables(c.Request.Context())
+		if err != nil {
+			utils.InternalServerError(c, "failed to load increment tables", err.Error())
+			return
+		}
+
+		utils.OK(c, "increment tables retrieved successfully", tables)
+	}
+}
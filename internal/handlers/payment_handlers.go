@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/joshua-takyi/auction/internal/constants"
+	"github.com/joshua-takyi/auction/internal/models"
+	"github.com/joshua-takyi/auction/internal/payments"
+	"github.com/joshua-takyi/auction/internal/service"
+	"github.com/joshua-takyi/auction/internal/utils"
+)
+
+func CheckoutHandler(s *service.PaymentService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		auctionParamId := c.Param(strings.TrimSpace("id"))
+		if auctionParamId == "" {
+			utils.BadRequest(c, "auction id can't be empty", auctionParamId)
+			return
+		}
+
+		auctionID, err := uuid.Parse(auctionParamId)
+		if err != nil {
+			utils.BadRequest(c, "invalid auction id", "auction_id")
+			return
+		}
+
+		user, exists := c.Get("user")
+		if !exists {
+			utils.Unauthorized(c, "user not authenticated", "user")
+			return
+		}
+
+		claims, ok := user.(*models.User)
+		if !ok {
+			utils.Unauthorized(c, "invalid user token", "user")
+			return
+		}
+
+		payment, err := s.Checkout(c.Request.Context(), auctionID, claims)
+		if err != nil {
+			switch {
+			case errors.Is(err, constants.ErrNotFound):
+				utils.NotFound(c, "no data found matching the id", "auction")
+			case errors.Is(err, constants.ErrNotWinner):
+				utils.Forbidden(c, err.Error(), "auction")
+			case errors.Is(err, constants.ErrAuctionNotPayable):
+				utils.Conflict(c, err.Error(), "auction")
+			default:
+				utils.InternalServerError(c, "failed to start checkout", err.Error())
+			}
+			return
+		}
+
+		utils.OK(c, "checkout started successfully", payment)
+	}
+}
+
+// PaystackWebhookHandler receives Paystack events. It is a public route; authenticity comes
+// from the x-paystack-signature header, which is checked against the raw body
+func PaystackWebhookHandler(s *service.PaymentService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		body, err := c.GetRawData()
+		if err != nil {
+			utils.BadRequest(c, "failed to read webhook body", err.Error())
+			return
+		}
+
+		err = s.HandleWebhook(c.Request.Context(), body, c.GetHeader("x-paystack-signature"))
+		if err != nil {
+			switch {
+			case errors.Is(err, payments.ErrInvalidSignature):
+				utils.Unauthorized(c, err.Error(), "signature")
+			case errors.Is(err, constants.ErrNotFound):
+				// Not one of our references; acknowledge so Paystack stops retrying
+				utils.OK(c, "webhook ignored", "")
+			default:
+				utils.InternalServerError(c, "failed to process webhook", err.Error())
+			}
+			return
+		}
+
+		utils.OK(c, "webhook processed", "")
+	}
+}
@@ -188,7 +188,7 @@ func GetProductWithAuctionHandler(s *service.ProductService) gin.HandlerFunc {
 			utils.BadRequest(c, "failed to parse string to uuid", "")
 			return
 		}
-		res, err := s.GetProductWithAuction(c.Request.Context(), productID)
+		res, err := s.GetProductWithAuction(c.Request.Context(), productID, optionalViewer(c))
 		if err != nil {
 			switch {
 			case errors.Is(err, constants.ErrNotFound):
@@ -1,10 +1,15 @@
 package handlers
 
 import (
+	"context"
+
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/joshua-takyi/auction/internal/models"
+	"github.com/joshua-takyi/auction/internal/service"
 	"github.com/joshua-takyi/auction/internal/utils"
 	"github.com/joshua-takyi/auction/internal/websockets"
+	"github.com/shopspring/decimal"
 )
 
 func CreateWSTicketHandler(m *websockets.Manager) gin.HandlerFunc {
@@ -28,3 +33,41 @@ func CreateWSTicketHandler(m *websockets.Manager) gin.HandlerFunc {
 		})
 	}
 }
+
+type wsBidPayload struct {
+	AuctionID string           `json:"auction_id"`
+	Amount    decimal.Decimal  `json:"amount"`
+	MaxAmount *decimal.Decimal `json:"max_amount"`
+	Quantity  int              `json:"quantity"`
+}
+
+// PlaceBidCommand places a bid sent over the websocket as the user the connection's ticket was
+// issued to. The room hears about it the same way as an HTTP bid; the sender also gets the
+// result as the command's ack
+func PlaceBidCommand(bidService *service.BidService) websockets.CommandHandler {
+	return func(ctx context.Context, client *websockets.Client, cmd websockets.Command) (any, error) {
+		var payload wsBidPayload
+		if err := cmd.DecodePayload(&payload); err != nil {
+			return nil, err
+		}
+
+		auctionID, err := uuid.Parse(payload.AuctionID)
+		if err != nil {
+			return nil, websockets.NewCommandError(websockets.ErrCodeInvalidPayload, "auction_id must be a valid id")
+		}
+		if !payload.Amount.IsPositive() {
+			return nil, websockets.NewCommandError(websockets.ErrCodeInvalidPayload, "amount must be greater than zero")
+		}
+
+		bidderID, err := uuid.Parse(client.UserID)
+		if err != nil {
+			return nil, websockets.NewCommandError(websockets.ErrCodeUnauthorized, "connection has no valid user")
+		}
+
+		result, err := bidService.PlaceBidAs(ctx, bidderID, payload.Amount, payload.MaxAmount, payload.Quantity, auctionID)
+		if err != nil {
+			return nil, websockets.NewCommandError(websockets.ErrCodeBidRejected, err.Error())
+		}
+		return result, nil
+	}
+}
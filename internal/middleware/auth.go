@@ -81,3 +81,37 @@ func AuthMiddleware(jwtManager *jwt.JWTManager, userService *service.UserService
 		c.Next()
 	}
 }
+
+// OptionalAuthMiddleware sets the user on the context when the request carries a valid access
+// token and lets the request through as a guest otherwise. It is meant for public GET routes
+// whose response depends on who is asking
+func OptionalAuthMiddleware(jwtManager *jwt.JWTManager, userService *service.UserService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, err := c.Cookie("access_token")
+		if err != nil || token == "" {
+			c.Next()
+			return
+		}
+
+		userAuth, err := jwtManager.VerifySupabaseToken(token)
+		if err != nil {
+			c.Next()
+			return
+		}
+
+		parsedUserID, err := uuid.Parse(userAuth.ID)
+		if err != nil {
+			c.Next()
+			return
+		}
+		user, err := userService.GetUserById(c.Request.Context(), parsedUserID, token)
+		if err != nil {
+			c.Next()
+			return
+		}
+
+		c.Set("user", user)
+		c.Set(AuthorizationPayloadKey, userAuth)
+		c.Next()
+	}
+}
@@ -4,35 +4,65 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/joshua-takyi/auction/internal/constants"
 	"github.com/shopspring/decimal"
+	"github.com/supabase-community/postgrest-go"
+	"github.com/supabase-community/supabase-go"
 )
 
 type Auction struct {
-	ID             uuid.UUID        `db:"id" json:"id"`
-	ProductID      uuid.UUID        `db:"product_id" json:"product_id" validate:"required"`
-	MinIncrement   decimal.Decimal  `db:"min_increment" json:"min_increment" validate:"required"`
-	ReservePrice   *decimal.Decimal `db:"reserve_price" json:"reserve_price" validate:"required"`
-	CurrentBid     decimal.Decimal  `db:"current_bid" json:"current_bid"`
-	BuyNowPrice    decimal.Decimal  `db:"buy_now_price" json:"buy_now_price"`
-	BuyNow         bool             `db:"buy_now" json:"buy_now"`
-	EstimatedPrice decimal.Decimal  `db:"estimated_price" json:"estimated_price" validate:"required"`
-	StartPrice     decimal.Decimal  `db:"start_price" json:"start_price" validate:"required"`
-	StartTime      time.Time        `db:"start_time" json:"start_time" validate:"required"`
-	EndTime        time.Time        `db:"end_time" json:"end_time" validate:"required"`
-	WinnerID       *uuid.UUID       `db:"winner_id" json:"winner_id"`
-	Status         string           `db:"status" json:"status"`
-	RoomID         uuid.UUID        `db:"room_id" json:"room_id"`
-	CreatedAt      time.Time        `db:"created_at" json:"created_at"`
-	UpdatedAt      time.Time        `db:"updated_at" json:"updated_at"`
+	ID                  uuid.UUID        `db:"id" json:"id"`
+	ProductID           uuid.UUID        `db:"product_id" json:"product_id" validate:"required"`
+	Type                AuctionType      `db:"type" json:"type"`
+	MinIncrement        decimal.Decimal  `db:"min_increment" json:"min_increment" validate:"required"`
+	IncrementTableID    *uuid.UUID       `db:"increment_table_id" json:"increment_table_id"`
+	ReservePrice        *decimal.Decimal `db:"reserve_price" json:"reserve_price" validate:"required"`
+	CurrentBid          decimal.Decimal  `db:"current_bid" json:"current_bid"`
+	BuyNowPrice         decimal.Decimal  `db:"buy_now_price" json:"buy_now_price"`
+	BuyNow              bool             `db:"buy_now" json:"buy_now"`
+	EstimatedPrice      decimal.Decimal  `db:"estimated_price" json:"estimated_price" validate:"required"`
+	StartPrice          decimal.Decimal  `db:"start_price" json:"start_price" validate:"required"`
+	Quantity            int              `db:"quantity" json:"quantity"`
+	StartTime           time.Time        `db:"start_time" json:"start_time" validate:"required"`
+	EndTime             time.Time        `db:"end_time" json:"end_time" validate:"required"`
+	WinnerID            *uuid.UUID       `db:"winner_id" json:"winner_id"`
+	Status              AuctionStatus    `db:"status" json:"status"`
+	RoomID              uuid.UUID        `db:"room_id" json:"room_id"`
+	PriceDropStep       decimal.Decimal  `db:"price_drop_step" json:"price_drop_step"`
+	PriceDropSecs       int              `db:"price_drop_secs" json:"price_drop_secs"`
+	ExtensionWindowSecs int              `db:"extension_window_secs" json:"extension_window_secs"`
+	ExtensionSecs       int              `db:"extension_secs" json:"extension_secs"`
+	MaxExtensions       *int             `db:"max_extensions" json:"max_extensions"`
+	ExtensionCount      int              `db:"extension_count" json:"extension_count"`
+	ResolvedAt          *time.Time       `db:"resolved_at" json:"resolved_at"`
+	PaymentDueAt        *time.Time       `db:"payment_due_at" json:"payment_due_at"`
+	RemindersSent       int              `db:"reminders_sent" json:"reminders_sent"`
+	EndingNoticesSent   int              `db:"ending_notices_sent" json:"ending_notices_sent"`
+	LapsedWinnerIDs     []uuid.UUID      `db:"lapsed_winner_ids" json:"lapsed_winner_ids"`
+	CancelledAt         *time.Time       `db:"cancelled_at" json:"cancelled_at"`
+	CancelledBy         *uuid.UUID       `db:"cancelled_by" json:"cancelled_by"`
+	CancelReason        *string          `db:"cancel_reason" json:"cancel_reason"`
+	ReserveMetAt        *time.Time       `db:"reserve_met_at" json:"reserve_met_at"`
+	RelistAttempts      int              `db:"relist_attempts" json:"relist_attempts"` // relists left if this attempt goes unsold
+	RelistDelaySecs     int              `db:"relist_delay_secs" json:"relist_delay_secs"`
+	RelistPriceDropPct  decimal.Decimal  `db:"relist_price_drop_pct" json:"relist_price_drop_pct"`
+	RelistAttempt       int              `db:"relist_attempt" json:"relist_attempt"` // 0 for the original listing
+	PreviousAuctionID   *uuid.UUID       `db:"previous_auction_id" json:"previous_auction_id"`
+	RelistedAt          *time.Time       `db:"relisted_at" json:"relisted_at"`
+	ReserveMet          *bool            `db:"-" json:"reserve_met,omitempty"` // only set by PresentTo
+	CreatedAt           time.Time        `db:"created_at" json:"created_at"`
+	UpdatedAt           time.Time        `db:"updated_at" json:"updated_at"`
 }
 
 type AuctionResponse struct {
 	Auction
-	Product Product `json:"products"`
+	Product    Product          `json:"products"`
+	NextMinBid *decimal.Decimal `json:"next_minimum_bid,omitempty"`
+	NextMaxBid *decimal.Decimal `json:"next_maximum_bid,omitempty"` // reverse auctions only
 }
 
 type AuctionFilter struct {
@@ -43,11 +73,316 @@ type AuctionFilter struct {
 	SortBy   string   `form:"sort_by"`
 }
 
+// AuctionUpdate holds the seller-editable fields of an auction. Nil fields are left unchanged
+type AuctionUpdate struct {
+	MinIncrement        *decimal.Decimal `json:"min_increment"`
+	IncrementTableID    *uuid.UUID       `json:"increment_table_id"`
+	ReservePrice        *decimal.Decimal `json:"reserve_price"`
+	BuyNowPrice         *decimal.Decimal `json:"buy_now_price"`
+	BuyNow              *bool            `json:"buy_now"`
+	EstimatedPrice      *decimal.Decimal `json:"estimated_price"`
+	StartPrice          *decimal.Decimal `json:"start_price"`
+	Quantity            *int             `json:"quantity"`
+	StartTime           *time.Time       `json:"start_time"`
+	EndTime             *time.Time       `json:"end_time"`
+	PriceDropStep       *decimal.Decimal `json:"price_drop_step"`
+	PriceDropSecs       *int             `json:"price_drop_secs"`
+	ExtensionWindowSecs *int             `json:"extension_window_secs"`
+	ExtensionSecs       *int             `json:"extension_secs"`
+	MaxExtensions       *int             `json:"max_extensions"`
+	RelistAttempts      *int             `json:"relist_attempts"`
+	RelistDelaySecs     *int             `json:"relist_delay_secs"`
+	RelistPriceDropPct  *decimal.Decimal `json:"relist_price_drop_pct"`
+}
+
+func (u *AuctionUpdate) IsEmpty() bool {
+	return len(u.ToMap()) == 0
+}
+
+// OnlyEndTimeOrReserve reports whether the update touches nothing but the end time and reserve
+func (u *AuctionUpdate) OnlyEndTimeOrReserve() bool {
+	fields := u.ToMap()
+	delete(fields, "end_time")
+	delete(fields, "reserve_price")
+	return len(fields) == 0
+}
+
+// ApplyTo copies the set fields onto the auction
+func (u *AuctionUpdate) ApplyTo(a *Auction) {
+	if u.MinIncrement != nil {
+		a.MinIncrement = *u.MinIncrement
+	}
+	if u.IncrementTableID != nil {
+		tableID := *u.IncrementTableID
+		a.IncrementTableID = &tableID
+	}
+	if u.ReservePrice != nil {
+		reserve := *u.ReservePrice
+		a.ReservePrice = &reserve
+	}
+	if u.BuyNowPrice != nil {
+		a.BuyNowPrice = *u.BuyNowPrice
+	}
+	if u.BuyNow != nil {
+		a.BuyNow = *u.BuyNow
+	}
+	if u.EstimatedPrice != nil {
+		a.EstimatedPrice = *u.EstimatedPrice
+	}
+	if u.StartPrice != nil {
+		a.StartPrice = *u.StartPrice
+	}
+	if u.Quantity != nil {
+		a.Quantity = *u.Quantity
+	}
+	if u.StartTime != nil {
+		a.StartTime = *u.StartTime
+	}
+	if u.EndTime != nil {
+		a.EndTime = *u.EndTime
+	}
+	if u.PriceDropStep != nil {
+		a.PriceDropStep = *u.PriceDropStep
+	}
+	if u.PriceDropSecs != nil {
+		a.PriceDropSecs = *u.PriceDropSecs
+	}
+	if u.ExtensionWindowSecs != nil {
+		a.ExtensionWindowSecs = *u.ExtensionWindowSecs
+	}
+	if u.ExtensionSecs != nil {
+		a.ExtensionSecs = *u.ExtensionSecs
+	}
+	if u.MaxExtensions != nil {
+		a.MaxExtensions = u.MaxExtensions
+	}
+	if u.RelistAttempts != nil {
+		a.RelistAttempts = *u.RelistAttempts
+	}
+	if u.RelistDelaySecs != nil {
+		a.RelistDelaySecs = *u.RelistDelaySecs
+	}
+	if u.RelistPriceDropPct != nil {
+		a.RelistPriceDropPct = *u.RelistPriceDropPct
+	}
+}
+
+// ToMap returns the set fields keyed by column name
+func (u *AuctionUpdate) ToMap() map[string]any {
+	fields := make(map[string]any)
+	if u.MinIncrement != nil {
+		fields["min_increment"] = *u.MinIncrement
+	}
+	if u.IncrementTableID != nil {
+		fields["increment_table_id"] = *u.IncrementTableID
+	}
+	if u.ReservePrice != nil {
+		fields["reserve_price"] = *u.ReservePrice
+	}
+	if u.BuyNowPrice != nil {
+		fields["buy_now_price"] = *u.BuyNowPrice
+	}
+	if u.BuyNow != nil {
+		fields["buy_now"] = *u.BuyNow
+	}
+	if u.EstimatedPrice != nil {
+		fields["estimated_price"] = *u.EstimatedPrice
+	}
+	if u.StartPrice != nil {
+		fields["start_price"] = *u.StartPrice
+	}
+	if u.Quantity != nil {
+		fields["quantity"] = *u.Quantity
+	}
+	if u.StartTime != nil {
+		fields["start_time"] = *u.StartTime
+	}
+	if u.EndTime != nil {
+		fields["end_time"] = *u.EndTime
+	}
+	if u.PriceDropStep != nil {
+		fields["price_drop_step"] = *u.PriceDropStep
+	}
+	if u.PriceDropSecs != nil {
+		fields["price_drop_secs"] = *u.PriceDropSecs
+	}
+	if u.ExtensionWindowSecs != nil {
+		fields["extension_window_secs"] = *u.ExtensionWindowSecs
+	}
+	if u.ExtensionSecs != nil {
+		fields["extension_secs"] = *u.ExtensionSecs
+	}
+	if u.MaxExtensions != nil {
+		fields["max_extensions"] = *u.MaxExtensions
+	}
+	if u.RelistAttempts != nil {
+		fields["relist_attempts"] = *u.RelistAttempts
+	}
+	if u.RelistDelaySecs != nil {
+		fields["relist_delay_secs"] = *u.RelistDelaySecs
+	}
+	if u.RelistPriceDropPct != nil {
+		fields["relist_price_drop_pct"] = *u.RelistPriceDropPct
+	}
+	return fields
+}
+
 // type SummaryResponse struct {
 // 	Auction
 // 	Products Product `json:"product"`
 // }
 
+// CanRelist reports whether the auction's relist rule has attempts left
+func (a *Auction) CanRelist() bool {
+	return a.RelistAttempts > 0
+}
+
+// Relist builds the next attempt at selling an unsold auction: same terms and duration, starting
+// RelistDelaySecs after this one ended (or now, if that has passed), with StartPrice and
+// ReservePrice lowered by RelistPriceDropPct. The new attempt carries the rule on with one
+// attempt fewer. IDs, creation times and status are left for CreateAuction
+func (a *Auction) Relist(now time.Time) *Auction {
+	next := *a
+	next.ID = uuid.Nil
+	next.RoomID = uuid.Nil
+	next.Status = ""
+	next.CurrentBid = decimal.Zero
+	next.WinnerID = nil
+	next.ExtensionCount = 0
+	next.ResolvedAt = nil
+	next.PaymentDueAt = nil
+	next.RemindersSent = 0
+	next.EndingNoticesSent = 0
+	next.LapsedWinnerIDs = nil
+	next.CancelledAt = nil
+	next.CancelledBy = nil
+	next.CancelReason = nil
+	next.ReserveMetAt = nil
+	next.ReserveMet = nil
+	next.RelistedAt = nil
+	next.RelistAttempts = a.RelistAttempts - 1
+	next.RelistAttempt = a.RelistAttempt + 1
+	previous := a.ID
+	next.PreviousAuctionID = &previous
+
+	next.StartTime = a.EndTime.Add(time.Duration(a.RelistDelaySecs) * time.Second)
+	if next.StartTime.Before(now) {
+		next.StartTime = now
+	}
+	next.EndTime = next.StartTime.Add(a.CalculateDuration())
+
+	if a.RelistPriceDropPct.IsPositive() {
+		factor := decimal.NewFromInt(1).Sub(a.RelistPriceDropPct.Div(decimal.NewFromInt(100)))
+		next.StartPrice = a.StartPrice.Mul(factor).Round(2)
+		if a.ReservePrice != nil {
+			reserve := a.ReservePrice.Mul(factor).Round(2)
+			next.ReservePrice = &reserve
+		}
+	}
+	return &next
+}
+
+// MeetsReserve reports whether price satisfies the reserve: at or above it, or at or below it
+// in a reverse auction where the reserve is a ceiling. No bid never meets it
+func (a *Auction) MeetsReserve(price decimal.Decimal) bool {
+	if price.IsZero() {
+		return false
+	}
+	if a.ReservePrice == nil {
+		return true
+	}
+	if a.Type == AuctionReverse {
+		return price.LessThanOrEqual(*a.ReservePrice)
+	}
+	return price.GreaterThanOrEqual(*a.ReservePrice)
+}
+
+// IsReserveMet reports whether bidding has reached the reserve
+func (a *Auction) IsReserveMet() bool {
+	return a.ReserveMetAt != nil || a.MeetsReserve(a.CurrentBid)
+}
+
+// PresentTo prepares the auction for a response to viewer (nil for guests). Only the owner and
+// admins see the reserve itself; everyone gets reserve_met instead
+func (a *Auction) PresentTo(viewer *User, ownerID uuid.UUID) {
+	met := a.IsReserveMet()
+	a.ReserveMet = &met
+	if viewer == nil || !viewer.IsAdminOrOwner(ownerID) {
+		a.ReservePrice = nil
+	}
+}
+
+// PresentTo prepares the auction for a response to viewer, see Auction.PresentTo
+func (r *AuctionResponse) PresentTo(viewer *User) {
+	r.Auction.PresentTo(viewer, r.Product.OwnerID)
+}
+
+// IncrementAt returns the bid increment on top of price, taken from table when the auction has
+// one and from MinIncrement otherwise
+func (a *Auction) IncrementAt(table *IncrementTable, price decimal.Decimal) decimal.Decimal {
+	if table == nil {
+		return a.MinIncrement
+	}
+	return table.IncrementAt(price)
+}
+
+// NextMinimumBid is the lowest bid the auction accepts next given the increment at the current
+// price. Until the first bid the start price itself is enough
+func (a *Auction) NextMinimumBid(increment decimal.Decimal) decimal.Decimal {
+	if a.CurrentBid.LessThan(a.StartPrice) {
+		return a.StartPrice
+	}
+	return a.CurrentBid.Add(increment)
+}
+
+// NextMaximumBid is the highest bid a reverse auction accepts next: the start price until the
+// first bid, then the current bid less the increment
+func (a *Auction) NextMaximumBid(increment decimal.Decimal) decimal.Decimal {
+	if a.CurrentBid.IsZero() || a.CurrentBid.GreaterThan(a.StartPrice) {
+		return a.StartPrice
+	}
+	return a.CurrentBid.Sub(increment)
+}
+
+// DutchPriceAt is a Dutch auction's asking price at t. It starts at StartPrice, drops by
+// PriceDropStep every PriceDropSecs and never goes below the reserve
+func (a *Auction) DutchPriceAt(t time.Time) decimal.Decimal {
+	floor := decimal.Zero
+	if a.ReservePrice != nil {
+		floor = *a.ReservePrice
+	}
+	if !t.After(a.StartTime) || a.PriceDropSecs <= 0 {
+		return decimal.Max(a.StartPrice, floor)
+	}
+
+	drops := int64(t.Sub(a.StartTime) / (time.Duration(a.PriceDropSecs) * time.Second))
+	price := a.StartPrice.Sub(a.PriceDropStep.Mul(decimal.NewFromInt(drops)))
+	return decimal.Max(price, floor)
+}
+
+// NextPriceDrop is when a Dutch auction's price next changes after t. ok is false once the price
+// has reached the reserve
+func (a *Auction) NextPriceDrop(t time.Time) (next time.Time, ok bool) {
+	if a.PriceDropSecs <= 0 || (a.ReservePrice != nil && !a.DutchPriceAt(t).GreaterThan(*a.ReservePrice)) {
+		return time.Time{}, false
+	}
+	interval := time.Duration(a.PriceDropSecs) * time.Second
+	if t.Before(a.StartTime) {
+		return a.StartTime.Add(interval), true
+	}
+	drops := t.Sub(a.StartTime)/interval + 1
+	return a.StartTime.Add(drops * interval), true
+}
+
+// LotMinimumPrice is the lowest unit price a multi-unit auction can clear at: the start price,
+// or the reserve when that is higher. It reveals the reserve, so it is only for settlement
+func (a *Auction) LotMinimumPrice() decimal.Decimal {
+	if a.ReservePrice != nil {
+		return decimal.Max(a.StartPrice, *a.ReservePrice)
+	}
+	return a.StartPrice
+}
+
 // calculateDuration is to calculate the duration of the auction
 func (a *Auction) CalculateDuration() time.Duration {
 	return a.EndTime.Sub(a.StartTime)
@@ -63,6 +398,45 @@ func (a *Auction) CalculateRemainingTime() time.Duration {
 	return time.Until(a.EndTime)
 }
 
+// ShouldExtend reports whether a bid placed at the given time falls inside the soft close window.
+// A bid within ExtensionWindowSecs of EndTime extends the auction, at most MaxExtensions times
+// (unlimited when nil)
+func (a *Auction) ShouldExtend(at time.Time) bool {
+	// Nobody can see a sealed bid to respond to it and Dutch auctions end on the first accept,
+	// so neither has anything to extend for
+	if a.Type.IsSealed() || !a.Type.TakesBids() || a.ExtensionWindowSecs <= 0 || at.After(a.EndTime) {
+		return false
+	}
+	if a.MaxExtensions != nil && a.ExtensionCount >= *a.MaxExtensions {
+		return false
+	}
+	return a.EndTime.Sub(at) <= time.Duration(a.ExtensionWindowSecs)*time.Second
+}
+
+// ExtendedEndTime is the end time after one soft close extension. The window length is used
+// when no explicit extension is configured
+func (a *Auction) ExtendedEndTime() time.Time {
+	secs := a.ExtensionSecs
+	if secs <= 0 {
+		secs = a.ExtensionWindowSecs
+	}
+	return a.EndTime.Add(time.Duration(secs) * time.Second)
+}
+
+// EndingNoticesPassed counts the ending-soon notices, given longest first, whose time before
+// endTime has already come by now. It is what ending_notices_sent becomes when the end moves,
+// so notices still ahead go out again and ones already behind aren't sent late
+func EndingNoticesPassed(endTime, now time.Time, notices []time.Duration) int {
+	passed := 0
+	for _, notice := range notices {
+		if now.Before(endTime.Add(-notice)) {
+			break
+		}
+		passed++
+	}
+	return passed
+}
+
 type AuctionInterface interface {
 	CreateAuction(ctx context.Context, auction *Auction, accessToken string, productID uuid.UUID) (*Auction, error)
 	UpdateAuction(ctx context.Context, auction map[string]any, accessToken string, auctionID uuid.UUID) (*Auction, error)
@@ -74,15 +448,31 @@ type AuctionInterface interface {
 	Recommendation(ctx context.Context, category string, currentID string, limit, offset int) ([]*AuctionResponse, int64, error)
 	SearchAuctions(ctx context.Context, query string, limit, offset int) ([]*AuctionResponse, int64, error)
 	FilterAuctions(ctx context.Context, filter AuctionFilter, limit, offset int) ([]*AuctionResponse, int64, error)
-	UpdateAuctionStatuses(ctx context.Context) (map[string]any, error)
+	ExtendAuction(ctx context.Context, auctionID uuid.UUID, endTime time.Time, previousCount, noticesSent int) (*Auction, error)
+	MarkReserveMet(ctx context.Context, auctionID uuid.UUID, at time.Time) error
+	GetRelistCandidates(ctx context.Context, limit int) ([]*AuctionResponse, error)
+	MarkRelisted(ctx context.Context, auctionID uuid.UUID, at *time.Time) error
+	GetAuctionsByStatus(ctx context.Context, status AuctionStatus, limit int) ([]*AuctionResponse, error)
+	GetUnresolvedAuctions(ctx context.Context, limit int) ([]*AuctionResponse, error)
+	ResolveAuction(ctx context.Context, auctionID, winnerID uuid.UUID, finalPrice decimal.Decimal, paymentDueAt time.Time) (*Auction, error)
+	GetAwaitingPaymentAuctions(ctx context.Context, limit int) ([]*AuctionResponse, error)
+	MarkReminderSent(ctx context.Context, auctionID uuid.UUID, remindersSent, previous int) error
+	GetEndingAuctions(ctx context.Context, before time.Time, notices, limit int) ([]*AuctionResponse, error)
+	MarkEndingNoticeSent(ctx context.Context, auctionID uuid.UUID, noticesSent, previous int) error
+	ReassignWinner(ctx context.Context, auctionID, previousWinner, newWinner uuid.UUID, price decimal.Decimal, paymentDueAt time.Time, lapsed []uuid.UUID) (*Auction, error)
+	TransitionAuction(ctx context.Context, auctionID uuid.UUID, from, to AuctionStatus, guard TransitionGuard, fields map[string]any) (*Auction, error)
+	RecordAuctionTransition(ctx context.Context, transition *AuctionTransition) error
+	GetDueAuctions(ctx context.Context, status AuctionStatus, timeColumn string, at time.Time, limit int) ([]*AuctionResponse, error)
 	GetAuctionSummary(ctx context.Context, userID uuid.UUID, limit, offset int, accessToken string) ([]AuctionResponse, error)
 	GetUserAuctions(ctx context.Context, userID uuid.UUID, limit, offset int, accessToken string) ([]AuctionResponse, int64, error)
 }
 
+// CreateAuction inserts a new auction. An empty access token inserts it with the service client,
+// which is how the worker relists unsold auctions on the seller's behalf
 func (sr *SupabaseRepo) CreateAuction(ctx context.Context, auction *Auction, accessToken string, productID uuid.UUID) (*Auction, error) {
-	client, err := sr.GetAuthenticatedClient(accessToken)
+	client, err := sr.clientFor(accessToken)
 	if err != nil {
-		return nil, constants.ErrNoClient
+		return nil, err
 	}
 
 	fmt.Printf("[SupabaseRepo] Inserting auction: %v\n", auction)
@@ -111,8 +501,46 @@ func (sr *SupabaseRepo) CreateAuction(ctx context.Context, auction *Auction, acc
 }
 
 func (sr *SupabaseRepo) UpdateAuction(ctx context.Context, auction map[string]any, accessToken string, auctionID uuid.UUID) (*Auction, error) {
+	var client *supabase.Client
+	var err error
+
+	if accessToken == "" {
+		if sr.serviceClient == nil {
+			return nil, constants.ErrNoClient
+		}
+		client = sr.serviceClient
+	} else {
+		client, err = sr.GetAuthenticatedClient(accessToken)
+		if err != nil {
+			return nil, constants.ErrNoClient
+		}
+	}
+
+	allowedFields := []string{
+		"min_increment", "increment_table_id", "reserve_price", "buy_now_price", "buy_now", "estimated_price", "start_price", "quantity",
+		"start_time", "end_time", "price_drop_step", "price_drop_secs", "extension_window_secs", "extension_secs", "max_extensions",
+		"relist_attempts", "relist_delay_secs", "relist_price_drop_pct", "ending_notices_sent", "updated_at",
+	}
+
+	for key := range auction {
+		if !contains(allowedFields, key) {
+			return nil, fmt.Errorf("field %s is not allowed", key)
+		}
+	}
 
-	return nil, nil
+	byteData, _, err := client.From(string(constants.AuctionTable)).Update(auction, "", "exact").Eq("id", auctionID.String()).Execute()
+	if err != nil {
+		return nil, fmt.Errorf("failed to update auction: %w", err)
+	}
+
+	var a []Auction
+	if err := json.Unmarshal(byteData, &a); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal auction: %w", err)
+	}
+	if len(a) == 0 {
+		return nil, constants.ErrNoData
+	}
+	return &a[0], nil
 }
 
 func (sr *SupabaseRepo) DeleteAuction(ctx context.Context, accessToken string, auctionID uuid.UUID) (string, error) {
@@ -171,9 +599,9 @@ func (sr *SupabaseRepo) GetAuctionsByProductID(ctx context.Context, accessToken
 }
 
 func (sr *SupabaseRepo) GetActiveAuctionByProductID(ctx context.Context, accessToken string, productID uuid.UUID) (*Auction, error) {
-	client, err := sr.GetAuthenticatedClient(accessToken)
+	client, err := sr.clientFor(accessToken)
 	if err != nil {
-		return nil, constants.ErrNoClient
+		return nil, err
 	}
 
 	res, count, err := client.From(string(constants.AuctionTable)).Select("*, products(*)", "exact", false).Eq("product_id", productID.String()).Eq("status", constants.AuctionLive).Execute()
@@ -192,7 +620,9 @@ func (sr *SupabaseRepo) GetActiveAuctionByProductID(ctx context.Context, accessT
 
 func (sr *SupabaseRepo) ListAuctions(ctx context.Context, limit, offset int) ([]*AuctionResponse, int64, error) {
 	byteData, count, err := sr.supabase.From(string(constants.AuctionTable)).
-		Select("*, products(*)", "exact", false).Limit(limit, "").
+		Select("*, products(*)", "exact", false).
+		Neq("status", constants.AuctionCancelled).
+		Limit(limit, "").
 		Range(offset, offset+limit-1, "").
 		Execute()
 
@@ -211,11 +641,14 @@ func (sr *SupabaseRepo) ListAuctions(ctx context.Context, limit, offset int) ([]
 	return response, count, nil
 }
 
+// SearchAuctions calls the search_auctions rpc. Cancelled auctions are excluded inside the rpc,
+// so total_count and the page both leave them out
 func (sr *SupabaseRepo) SearchAuctions(ctx context.Context, query string, limit, offset int) ([]*AuctionResponse, int64, error) {
 	params := map[string]any{
-		"query_text": query,
-		"p_limit":    limit,
-		"p_offset":   offset,
+		"query_text":       query,
+		"p_exclude_status": constants.AuctionCancelled,
+		"p_limit":          limit,
+		"p_offset":         offset,
 	}
 
 	byteData, _, err := sr.supabase.From("rpc/search_auctions").Insert(params, false, "", "", "exact").Execute()
@@ -253,15 +686,17 @@ func (sr *SupabaseRepo) SearchAuctions(ctx context.Context, query string, limit,
 	return finalResponse, results[0].TotalCount, nil
 }
 
+// FilterAuctions calls the filter_auctions rpc, which excludes cancelled auctions the same way
 func (sr *SupabaseRepo) FilterAuctions(ctx context.Context, filter AuctionFilter, limit, offset int) ([]*AuctionResponse, int64, error) {
 	params := map[string]any{
-		"p_category":  filter.Category,
-		"p_min_price": filter.MinPrice,
-		"p_max_price": filter.MaxPrice,
-		"p_status":    filter.Status,
-		"p_sort_by":   filter.SortBy,
-		"p_limit":     limit,
-		"p_offset":    offset,
+		"p_category":       filter.Category,
+		"p_min_price":      filter.MinPrice,
+		"p_max_price":      filter.MaxPrice,
+		"p_status":         filter.Status,
+		"p_sort_by":        filter.SortBy,
+		"p_limit":          limit,
+		"p_offset":         offset,
+		"p_exclude_status": constants.AuctionCancelled,
 	}
 
 	byteData, _, err := sr.supabase.From("rpc/filter_auctions").Insert(params, false, "", "", "exact").Execute()
@@ -299,25 +734,355 @@ func (sr *SupabaseRepo) FilterAuctions(ctx context.Context, filter AuctionFilter
 	return finalResponse, results[0].TotalCount, nil
 }
 
-func (sr *SupabaseRepo) UpdateAuctionStatuses(ctx context.Context) (map[string]any, error) {
-	// Call the RPC without any arguments
-	res, _, err := sr.supabase.From("rpc/update_auction_statuses").Insert(map[string]any{}, false, "", "", "exact").Execute()
+// ExtendAuction moves an auction's end time for the soft close and resets ending_notices_sent to
+// noticesSent. The update only applies while extension_count still matches previousCount, so
+// two instances can't extend for the same bid
+func (sr *SupabaseRepo) ExtendAuction(ctx context.Context, auctionID uuid.UUID, endTime time.Time, previousCount, noticesSent int) (*Auction, error) {
+	if sr.serviceClient == nil {
+		return nil, constants.ErrNoClient
+	}
+
+	update := map[string]any{
+		"end_time":            endTime,
+		"extension_count":     previousCount + 1,
+		"ending_notices_sent": noticesSent,
+		"updated_at":          time.Now(),
+	}
+
+	byteData, _, err := sr.serviceClient.From(string(constants.AuctionTable)).
+		Update(update, "", "exact").
+		Eq("id", auctionID.String()).
+		Eq("extension_count", strconv.Itoa(previousCount)).
+		Eq("status", constants.AuctionLive).
+		Execute()
 	if err != nil {
-		return nil, fmt.Errorf("failed to call update_auction_statuses rpc: %w", err)
+		return nil, fmt.Errorf("failed to extend auction: %w", err)
 	}
 
-	var result map[string]any
-	if err := json.Unmarshal([]byte(res), &result); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal rpc result: %w", err)
+	var a []Auction
+	if err := json.Unmarshal(byteData, &a); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal auction: %w", err)
 	}
+	if len(a) == 0 {
+		return nil, constants.ErrNoData
+	}
+	return &a[0], nil
+}
 
-	return result, nil
+// MarkReserveMet records when bidding first reached the reserve. It returns ErrNoData when that
+// was already recorded, so only one caller gets to announce it
+func (sr *SupabaseRepo) MarkReserveMet(ctx context.Context, auctionID uuid.UUID, at time.Time) error {
+	if sr.serviceClient == nil {
+		return constants.ErrNoClient
+	}
+
+	byteData, _, err := sr.serviceClient.From(string(constants.AuctionTable)).
+		Update(map[string]any{"reserve_met_at": at}, "", "exact").
+		Eq("id", auctionID.String()).
+		Is("reserve_met_at", "null").
+		Execute()
+	if err != nil {
+		return fmt.Errorf("failed to mark reserve met: %w", err)
+	}
+
+	var a []Auction
+	if err := json.Unmarshal(byteData, &a); err != nil {
+		return fmt.Errorf("failed to unmarshal auction: %w", err)
+	}
+	if len(a) == 0 {
+		return constants.ErrNoData
+	}
+	return nil
+}
+
+// GetRelistCandidates loads SETTLED auctions without a winner that have relist attempts left and
+// haven't been relisted yet, oldest first. Whether the product is still unsold is left to the
+// caller
+func (sr *SupabaseRepo) GetRelistCandidates(ctx context.Context, limit int) ([]*AuctionResponse, error) {
+	if sr.serviceClient == nil {
+		return nil, constants.ErrNoClient
+	}
+
+	byteData, _, err := sr.serviceClient.From(string(constants.AuctionTable)).
+		Select("*, products(*)", "exact", false).
+		Eq("status", string(constants.AuctionSettled)).
+		Is("winner_id", "null").
+		Is("relisted_at", "null").
+		Gt("relist_attempts", "0").
+		Order("end_time", &postgrest.OrderOpts{Ascending: true}).
+		Limit(limit, "").
+		Execute()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load relist candidates: %w", err)
+	}
+
+	var res []*AuctionResponse
+	if err := json.Unmarshal(byteData, &res); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal auctions: %w", err)
+	}
+	return res, nil
+}
+
+// MarkRelisted sets or clears relisted_at. Setting it only succeeds while it is still unset and
+// returns ErrNoData otherwise, so only one worker relists an auction
+func (sr *SupabaseRepo) MarkRelisted(ctx context.Context, auctionID uuid.UUID, at *time.Time) error {
+	if sr.serviceClient == nil {
+		return constants.ErrNoClient
+	}
+
+	query := sr.serviceClient.From(string(constants.AuctionTable)).
+		Update(map[string]any{"relisted_at": at}, "", "exact").
+		Eq("id", auctionID.String())
+	if at != nil {
+		query = query.Is("relisted_at", "null")
+	}
+
+	byteData, _, err := query.Execute()
+	if err != nil {
+		return fmt.Errorf("failed to mark auction relisted: %w", err)
+	}
+
+	var a []Auction
+	if err := json.Unmarshal(byteData, &a); err != nil {
+		return fmt.Errorf("failed to unmarshal auction: %w", err)
+	}
+	if len(a) == 0 {
+		return constants.ErrNoData
+	}
+	return nil
+}
+
+// GetAuctionsByStatus loads up to limit auctions in the given status, oldest end time first.
+// It is used by background jobs, so it always reads with the service client
+func (sr *SupabaseRepo) GetAuctionsByStatus(ctx context.Context, status AuctionStatus, limit int) ([]*AuctionResponse, error) {
+	if sr.serviceClient == nil {
+		return nil, constants.ErrNoClient
+	}
+
+	byteData, _, err := sr.serviceClient.From(string(constants.AuctionTable)).
+		Select("*, products(*)", "exact", false).
+		Eq("status", string(status)).
+		Order("end_time", &postgrest.OrderOpts{Ascending: true}).
+		Limit(limit, "").
+		Execute()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get auctions by status: %w", err)
+	}
+
+	var res []*AuctionResponse
+	if err := json.Unmarshal(byteData, &res); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal auctions: %w", err)
+	}
+	return res, nil
+}
+
+// GetUnresolvedAuctions loads ENDED auctions whose result has not been recorded yet
+func (sr *SupabaseRepo) GetUnresolvedAuctions(ctx context.Context, limit int) ([]*AuctionResponse, error) {
+	if sr.serviceClient == nil {
+		return nil, constants.ErrNoClient
+	}
+
+	byteData, _, err := sr.serviceClient.From(string(constants.AuctionTable)).
+		Select("*, products(*)", "exact", false).
+		Eq("status", constants.AuctionEnded).
+		Is("resolved_at", "null").
+		Order("end_time", &postgrest.OrderOpts{Ascending: true}).
+		Limit(limit, "").
+		Execute()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get unresolved auctions: %w", err)
+	}
+
+	var res []*AuctionResponse
+	if err := json.Unmarshal(byteData, &res); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal auctions: %w", err)
+	}
+	return res, nil
+}
+
+// ResolveAuction records the winner of an ended auction, which stays ENDED until it is paid for.
+// The update only applies while the auction is unresolved, which makes resolving twice a no-op.
+// Unsold auctions are resolved by their transition to SETTLED instead
+func (sr *SupabaseRepo) ResolveAuction(ctx context.Context, auctionID, winnerID uuid.UUID, finalPrice decimal.Decimal, paymentDueAt time.Time) (*Auction, error) {
+	if sr.serviceClient == nil {
+		return nil, constants.ErrNoClient
+	}
+
+	now := time.Now()
+	update := map[string]any{
+		"winner_id":      winnerID,
+		"current_bid":    finalPrice,
+		"resolved_at":    now,
+		"payment_due_at": paymentDueAt,
+		"reminders_sent": 0,
+		"updated_at":     now,
+	}
+
+	byteData, _, err := sr.serviceClient.From(string(constants.AuctionTable)).
+		Update(update, "", "exact").
+		Eq("id", auctionID.String()).
+		Eq("status", constants.AuctionEnded).
+		Is("resolved_at", "null").
+		Execute()
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve auction: %w", err)
+	}
+
+	var a []Auction
+	if err := json.Unmarshal(byteData, &a); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal auction: %w", err)
+	}
+	if len(a) == 0 {
+		return nil, constants.ErrNoData
+	}
+	return &a[0], nil
+}
+
+// GetAwaitingPaymentAuctions loads won auctions that have not been paid for yet
+func (sr *SupabaseRepo) GetAwaitingPaymentAuctions(ctx context.Context, limit int) ([]*AuctionResponse, error) {
+	if sr.serviceClient == nil {
+		return nil, constants.ErrNoClient
+	}
+
+	byteData, _, err := sr.serviceClient.From(string(constants.AuctionTable)).
+		Select("*, products(*)", "exact", false).
+		Eq("status", constants.AuctionEnded).
+		Not("resolved_at", "is", "null").
+		Not("winner_id", "is", "null").
+		Order("payment_due_at", &postgrest.OrderOpts{Ascending: true}).
+		Limit(limit, "").
+		Execute()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get auctions awaiting payment: %w", err)
+	}
+
+	var res []*AuctionResponse
+	if err := json.Unmarshal(byteData, &res); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal auctions: %w", err)
+	}
+	return res, nil
+}
+
+// MarkReminderSent records how many payment reminders have gone out. It only applies while
+// the count is still previous, so reminders aren't sent twice across workers
+func (sr *SupabaseRepo) MarkReminderSent(ctx context.Context, auctionID uuid.UUID, remindersSent, previous int) error {
+	if sr.serviceClient == nil {
+		return constants.ErrNoClient
+	}
+
+	update := map[string]any{
+		"reminders_sent": remindersSent,
+		"updated_at":     time.Now(),
+	}
+
+	_, count, err := sr.serviceClient.From(string(constants.AuctionTable)).
+		Update(update, "", "exact").
+		Eq("id", auctionID.String()).
+		Eq("reminders_sent", strconv.Itoa(previous)).
+		Execute()
+	if err != nil {
+		return fmt.Errorf("failed to record payment reminder: %w", err)
+	}
+	if count == 0 {
+		return constants.ErrNoData
+	}
+	return nil
+}
+
+// GetEndingAuctions loads LIVE auctions ending at or before before that have had fewer than
+// notices ending-soon notices, soonest first
+func (sr *SupabaseRepo) GetEndingAuctions(ctx context.Context, before time.Time, notices, limit int) ([]*AuctionResponse, error) {
+	if sr.serviceClient == nil {
+		return nil, constants.ErrNoClient
+	}
+
+	byteData, _, err := sr.serviceClient.From(string(constants.AuctionTable)).
+		Select("*, products(*)", "exact", false).
+		Eq("status", string(constants.AuctionLive)).
+		Lte("end_time", before.UTC().Format(time.RFC3339Nano)).
+		Lt("ending_notices_sent", strconv.Itoa(notices)).
+		Order("end_time", &postgrest.OrderOpts{Ascending: true}).
+		Limit(limit, "").
+		Execute()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ending auctions: %w", err)
+	}
+
+	var res []*AuctionResponse
+	if err := json.Unmarshal(byteData, &res); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal auctions: %w", err)
+	}
+	return res, nil
+}
+
+// MarkEndingNoticeSent records that noticesSent ending-soon notices have gone out. It only applies
+// while the count is still previous, so two workers can't send the same notice
+func (sr *SupabaseRepo) MarkEndingNoticeSent(ctx context.Context, auctionID uuid.UUID, noticesSent, previous int) error {
+	if sr.serviceClient == nil {
+		return constants.ErrNoClient
+	}
+
+	update := map[string]any{
+		"ending_notices_sent": noticesSent,
+		"updated_at":          time.Now(),
+	}
+
+	_, count, err := sr.serviceClient.From(string(constants.AuctionTable)).
+		Update(update, "", "exact").
+		Eq("id", auctionID.String()).
+		Eq("ending_notices_sent", strconv.Itoa(previous)).
+		Execute()
+	if err != nil {
+		return fmt.Errorf("failed to record ending notice: %w", err)
+	}
+	if count == 0 {
+		return constants.ErrNoData
+	}
+	return nil
+}
+
+// ReassignWinner cancels an unpaid win and hands the lot to newWinner. It only applies while
+// previousWinner still holds the unpaid win. When nobody is left the auction is instead moved
+// to SETTLED through its state machine
+func (sr *SupabaseRepo) ReassignWinner(ctx context.Context, auctionID, previousWinner, newWinner uuid.UUID, price decimal.Decimal, paymentDueAt time.Time, lapsed []uuid.UUID) (*Auction, error) {
+	if sr.serviceClient == nil {
+		return nil, constants.ErrNoClient
+	}
+
+	update := map[string]any{
+		"winner_id":         newWinner,
+		"current_bid":       price,
+		"payment_due_at":    paymentDueAt,
+		"reminders_sent":    0,
+		"lapsed_winner_ids": lapsed,
+		"updated_at":        time.Now(),
+	}
+
+	byteData, _, err := sr.serviceClient.From(string(constants.AuctionTable)).
+		Update(update, "", "exact").
+		Eq("id", auctionID.String()).
+		Eq("status", constants.AuctionEnded).
+		Eq("winner_id", previousWinner.String()).
+		Execute()
+	if err != nil {
+		return nil, fmt.Errorf("failed to reassign auction winner: %w", err)
+	}
+
+	var a []Auction
+	if err := json.Unmarshal(byteData, &a); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal auction: %w", err)
+	}
+	if len(a) == 0 {
+		return nil, constants.ErrNoData
+	}
+	return &a[0], nil
 }
 
 func (sr *SupabaseRepo) Recommendation(ctx context.Context, category string, currentID string, limit, offset int) ([]*AuctionResponse, int64, error) {
 	query := sr.supabase.From(string(constants.AuctionTable)).
 		Select("*, products!inner(*)", "exact", false).
-		Ilike("products.category", category)
+		Ilike("products.category", category).
+		Neq("status", constants.AuctionCancelled)
 
 	if currentID != "" {
 		query = query.Neq("id", currentID)
@@ -0,0 +1,160 @@
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/joshua-takyi/auction/internal/constants"
+	"github.com/supabase-community/postgrest-go"
+)
+
+// AuctionStatus is a state in the auction lifecycle:
+//
+//	SCHEDULED -> LIVE -> ENDED -> SETTLED
+//
+// with SCHEDULED and LIVE also able to move to CANCELLED. SETTLED and CANCELLED are final
+type AuctionStatus string
+
+var auctionTransitions = map[AuctionStatus][]AuctionStatus{
+	constants.AuctionScheduled: {constants.AuctionLive, constants.AuctionCancelled},
+	constants.AuctionLive:      {constants.AuctionEnded, constants.AuctionCancelled},
+	constants.AuctionEnded:     {constants.AuctionSettled},
+}
+
+// CanTransitionTo reports whether the lifecycle allows moving from s to next
+func (s AuctionStatus) CanTransitionTo(next AuctionStatus) bool {
+	for _, allowed := range auctionTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// IsFinal reports whether no further transitions are possible
+func (s AuctionStatus) IsFinal() bool {
+	return len(auctionTransitions[s]) == 0
+}
+
+// TransitionTrigger records what caused a status change
+type TransitionTrigger string
+
+const (
+	TriggerCreated      TransitionTrigger = "created"
+	TriggerStartTime    TransitionTrigger = "start_time_reached"
+	TriggerEndTime      TransitionTrigger = "end_time_reached"
+	TriggerBuyNow       TransitionTrigger = "buy_now"
+	TriggerDutchAccept  TransitionTrigger = "dutch_accept"
+	TriggerSellerCancel TransitionTrigger = "seller_cancelled"
+	TriggerAdminCancel  TransitionTrigger = "admin_cancelled"
+	TriggerUnsold       TransitionTrigger = "settled_unsold"
+	TriggerLotCleared   TransitionTrigger = "lot_cleared"
+	TriggerAwarded      TransitionTrigger = "contract_awarded"
+	TriggerPayment      TransitionTrigger = "payment_confirmed"
+	TriggerPaymentLapse TransitionTrigger = "payment_lapsed"
+)
+
+// AuctionTransition is one entry in an auction's status history. ActorID is the user behind the
+// change, or nil when the system made it
+type AuctionTransition struct {
+	ID         uuid.UUID         `db:"id" json:"id"`
+	AuctionID  uuid.UUID         `db:"auction_id" json:"auction_id"`
+	FromStatus AuctionStatus     `db:"from_status" json:"from_status"`
+	ToStatus   AuctionStatus     `db:"to_status" json:"to_status"`
+	Trigger    TransitionTrigger `db:"trigger" json:"trigger"`
+	ActorID    *uuid.UUID        `db:"actor_id" json:"actor_id"`
+	Reason     string            `db:"reason" json:"reason"`
+	CreatedAt  time.Time         `db:"created_at" json:"created_at"`
+}
+
+// TransitionGuard adds conditions to a status change on top of the expected current status.
+// The change only applies when every condition still holds, which keeps racing writers out
+type TransitionGuard struct {
+	Eq     map[string]string    // column equals value
+	IsNull []string             // column is null
+	Before map[string]time.Time // column is at or before the time
+}
+
+// TransitionAuction moves an auction from one status to another and writes fields alongside
+// the status in the same update. It returns ErrNoData when the auction is no longer in from or
+// the guard fails. Callers go through AuctionStateMachine rather than using this directly
+func (sr *SupabaseRepo) TransitionAuction(ctx context.Context, auctionID uuid.UUID, from, to AuctionStatus, guard TransitionGuard, fields map[string]any) (*Auction, error) {
+	if sr.serviceClient == nil {
+		return nil, constants.ErrNoClient
+	}
+
+	update := make(map[string]any, len(fields)+2)
+	for key, value := range fields {
+		update[key] = value
+	}
+	update["status"] = to
+	update["updated_at"] = time.Now()
+
+	query := sr.serviceClient.From(string(constants.AuctionTable)).
+		Update(update, "", "exact").
+		Eq("id", auctionID.String()).
+		Eq("status", string(from))
+	for column, value := range guard.Eq {
+		query = query.Eq(column, value)
+	}
+	for _, column := range guard.IsNull {
+		query = query.Is(column, "null")
+	}
+	for column, at := range guard.Before {
+		query = query.Lte(column, at.UTC().Format(time.RFC3339Nano))
+	}
+
+	byteData, _, err := query.Execute()
+	if err != nil {
+		return nil, fmt.Errorf("failed to move auction to %s: %w", to, err)
+	}
+
+	var a []Auction
+	if err := json.Unmarshal(byteData, &a); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal auction: %w", err)
+	}
+	if len(a) == 0 {
+		return nil, constants.ErrNoData
+	}
+	return &a[0], nil
+}
+
+func (sr *SupabaseRepo) RecordAuctionTransition(ctx context.Context, transition *AuctionTransition) error {
+	if sr.serviceClient == nil {
+		return constants.ErrNoClient
+	}
+
+	_, _, err := sr.serviceClient.From(string(constants.AuctionTransitionTable)).Insert(transition, false, "", "", "exact").Execute()
+	if err != nil {
+		return fmt.Errorf("failed to record auction transition: %w", err)
+	}
+	return nil
+}
+
+// GetDueAuctions loads auctions in status whose timeColumn (start_time or end_time) is at or
+// before at, earliest first. The worker uses it to find auctions to start and end
+func (sr *SupabaseRepo) GetDueAuctions(ctx context.Context, status AuctionStatus, timeColumn string, at time.Time, limit int) ([]*AuctionResponse, error) {
+	if sr.serviceClient == nil {
+		return nil, constants.ErrNoClient
+	}
+
+	byteData, _, err := sr.serviceClient.From(string(constants.AuctionTable)).
+		Select("*, products(*)", "exact", false).
+		Eq("status", string(status)).
+		Lte(timeColumn, at.UTC().Format(time.RFC3339Nano)).
+		Order(timeColumn, &postgrest.OrderOpts{Ascending: true}).
+		Limit(limit, "").
+		Execute()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get due auctions: %w", err)
+	}
+
+	var res []*AuctionResponse
+	if err := json.Unmarshal(byteData, &res); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal auctions: %w", err)
+	}
+	return res, nil
+}
@@ -0,0 +1,42 @@
+package models
+
+// AuctionType is the bidding format of an auction
+type AuctionType string
+
+const (
+	// AuctionEnglish is an open ascending auction where every bid is public
+	AuctionEnglish AuctionType = "ENGLISH"
+	// AuctionSealedFirstPrice hides bids until close; the highest bidder pays their own bid
+	AuctionSealedFirstPrice AuctionType = "SEALED_FIRST_PRICE"
+	// AuctionSealedSecondPrice (Vickrey) hides bids until close; the highest bidder pays the
+	// second-highest bid
+	AuctionSealedSecondPrice AuctionType = "SEALED_SECOND_PRICE"
+	// AuctionDutch starts high and drops by a fixed step on a fixed interval; the first bidder
+	// to accept the current price wins
+	AuctionDutch AuctionType = "DUTCH"
+	// AuctionMultiUnit sells Quantity identical units. Bids name a quantity and a unit price and
+	// every winner pays the same clearing price
+	AuctionMultiUnit AuctionType = "MULTI_UNIT"
+	// AuctionReverse is a procurement auction: suppliers bid the price down, the lowest bid wins
+	// and the reserve is the most the buyer will pay
+	AuctionReverse AuctionType = "REVERSE"
+)
+
+// IsValid reports whether t is a known format. An empty type is treated as English
+func (t AuctionType) IsValid() bool {
+	switch t {
+	case "", AuctionEnglish, AuctionSealedFirstPrice, AuctionSealedSecondPrice, AuctionDutch, AuctionMultiUnit, AuctionReverse:
+		return true
+	}
+	return false
+}
+
+// IsSealed reports whether bids stay hidden until the auction closes
+func (t AuctionType) IsSealed() bool {
+	return t == AuctionSealedFirstPrice || t == AuctionSealedSecondPrice
+}
+
+// TakesBids reports whether the auction is won by bidding rather than by accepting a price
+func (t AuctionType) TakesBids() bool {
+	return t != AuctionDutch
+}
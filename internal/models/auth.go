@@ -42,6 +42,10 @@ func (u *User) IsAdminOrSeller() bool {
 	return u.Role == "admin" || u.Role == "seller"
 }
 
+func (u *User) IsAdmin() bool {
+	return u.Role == "admin"
+}
+
 func (u *User) IsAdminOrOwner(targetID uuid.UUID) bool {
 	if u.Role == "admin" {
 		return true
@@ -10,6 +10,7 @@ import (
 	"github.com/joshua-takyi/auction/internal/constants"
 	"github.com/shopspring/decimal"
 	"github.com/supabase-community/postgrest-go"
+	"github.com/supabase-community/supabase-go"
 )
 
 type Bid struct {
@@ -21,6 +22,7 @@ type Bid struct {
 	CreatedAt time.Time       `db:"created_at" json:"created_at"`
 	UpdatedAt time.Time       `db:"updated_at" json:"updated_at"`
 	IsWinning bool            `db:"is_winning" json:"is_winning"`
+	Quantity  int             `db:"-" json:"quantity,omitempty"` // only set for multi-unit auctions
 	Profile   *User           `json:"profiles"`
 }
 
@@ -28,12 +30,26 @@ type BidInterface interface {
 	PlaceBid(ctx context.Context, auctionID, bidderID uuid.UUID, amount decimal.Decimal, accessToken string) (map[string]any, error)
 	GetBids(ctx context.Context, auctionID uuid.UUID, accessToken string, limit, offset int) ([]*Bid, int64, error)
 	GetUserAuctionWithBid(ctx context.Context, userID uuid.UUID, accessToken string) ([]any, error)
+	GetAllBids(ctx context.Context, auctionID uuid.UUID) ([]*Bid, error)
+	PlaceReverseBid(ctx context.Context, auctionID, bidderID uuid.UUID, amount, previous decimal.Decimal) (*Bid, error)
 }
 
+// PlaceBid calls the place_bid rpc. An empty access token places the bid with the
+// service client, which is how proxy bids are submitted on behalf of other bidders.
 func (sr *SupabaseRepo) PlaceBid(ctx context.Context, auctionID, bidderID uuid.UUID, amount decimal.Decimal, accessToken string) (map[string]any, error) {
-	client, err := sr.GetAuthenticatedClient(accessToken)
-	if err != nil {
-		return nil, constants.ErrNoClient
+	var client *supabase.Client
+	var err error
+
+	if accessToken == "" {
+		if sr.serviceClient == nil {
+			return nil, constants.ErrNoClient
+		}
+		client = sr.serviceClient
+	} else {
+		client, err = sr.GetAuthenticatedClient(accessToken)
+		if err != nil {
+			return nil, constants.ErrNoClient
+		}
 	}
 
 	params := map[string]any{
@@ -57,6 +73,37 @@ func (sr *SupabaseRepo) PlaceBid(ctx context.Context, auctionID, bidderID uuid.U
 	return result, nil
 }
 
+// PlaceReverseBid calls the place_reverse_bid rpc. The place_bid rpc only accepts bids that
+// raise the price, so this one claims the new low with a guard on the previous current_bid,
+// clears the old winning bid and inserts the new one in a single transaction. The rpc returns
+// the new bid, or null when another bid got in first or the auction is no longer open, which is
+// reported as ErrNoData
+func (sr *SupabaseRepo) PlaceReverseBid(ctx context.Context, auctionID, bidderID uuid.UUID, amount, previous decimal.Decimal) (*Bid, error) {
+	if sr.serviceClient == nil {
+		return nil, constants.ErrNoClient
+	}
+
+	params := map[string]any{
+		"p_auction_id": auctionID.String(),
+		"p_bidder_id":  bidderID.String(),
+		"p_amount":     amount,
+		"p_previous":   previous,
+	}
+	res, _, err := sr.serviceClient.From("rpc/place_reverse_bid").Insert(params, false, "", "", "exact").Execute()
+	if err != nil {
+		return nil, fmt.Errorf("failed to call place_reverse_bid rpc: %w", err)
+	}
+
+	var bid *Bid
+	if err := json.Unmarshal(res, &bid); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal rpc result: %w", err)
+	}
+	if bid == nil {
+		return nil, constants.ErrNoData
+	}
+	return bid, nil
+}
+
 func (sr *SupabaseRepo) GetBids(ctx context.Context, auctionID uuid.UUID, accessToken string, limit, offset int) ([]*Bid, int64, error) {
 	client := sr.supabase
 	if sr.serviceClient != nil {
@@ -124,6 +171,11 @@ func (sr *SupabaseRepo) GetUserAuctionWithBid(ctx context.Context, userID uuid.U
 		}
 
 		if !uniqueAuctions[auctionID] {
+			if auction, ok := bid["auctions"].(map[string]any); ok {
+				if err := hideReserve(auction); err != nil {
+					return nil, err
+				}
+			}
 			latestBids = append(latestBids, bid)
 			uniqueAuctions[auctionID] = true
 		}
@@ -131,3 +183,43 @@ func (sr *SupabaseRepo) GetUserAuctionWithBid(ctx context.Context, userID uuid.U
 
 	return latestBids, nil
 }
+
+// hideReserve swaps an embedded auction's reserve_price for reserve_met, the same way
+// Auction.PresentTo does for anyone who isn't the owner or an admin
+func hideReserve(auction map[string]any) error {
+	raw, err := json.Marshal(auction)
+	if err != nil {
+		return fmt.Errorf("failed to marshal auction: %w", err)
+	}
+	var a Auction
+	if err := json.Unmarshal(raw, &a); err != nil {
+		return fmt.Errorf("failed to unmarshal auction: %w", err)
+	}
+	auction["reserve_met"] = a.IsReserveMet()
+	delete(auction, "reserve_price")
+	return nil
+}
+
+// GetAllBids returns every bid on an auction, highest first and earliest first among equal
+// amounts. It is used by background jobs, so it always reads with the service client.
+func (sr *SupabaseRepo) GetAllBids(ctx context.Context, auctionID uuid.UUID) ([]*Bid, error) {
+	if sr.serviceClient == nil {
+		return nil, constants.ErrNoClient
+	}
+
+	byteData, _, err := sr.serviceClient.From(string(constants.BidTable)).
+		Select("*", "exact", false).
+		Eq("auction_id", auctionID.String()).
+		Order("bid_amount", &postgrest.OrderOpts{Ascending: false}).
+		Order("created_at", &postgrest.OrderOpts{Ascending: true}).
+		Execute()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load bids for auction: %w", err)
+	}
+
+	var res []*Bid
+	if err := json.Unmarshal(byteData, &res); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal bids: %w", err)
+	}
+	return res, nil
+}
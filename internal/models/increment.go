@@ -0,0 +1,191 @@
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/joshua-takyi/auction/internal/constants"
+	"github.com/shopspring/decimal"
+	"github.com/supabase-community/postgrest-go"
+)
+
+// IncrementBand sets the bid increment for prices from From up to the next band
+type IncrementBand struct {
+	From      decimal.Decimal `json:"from"`
+	Increment decimal.Decimal `json:"increment"`
+}
+
+// IncrementTable is a bid increment schedule, like the ones traditional auction houses publish.
+// A table without a category is global and applies to every category without its own table
+type IncrementTable struct {
+	ID        uuid.UUID       `db:"id" json:"id"`
+	Name      string          `db:"name" json:"name" validate:"required"`
+	Category  *string         `db:"category" json:"category"`
+	Bands     []IncrementBand `db:"bands" json:"bands" validate:"required,min=1"`
+	CreatedAt time.Time       `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time       `db:"updated_at" json:"updated_at"`
+}
+
+// Normalize sorts the bands by their starting price
+func (t *IncrementTable) Normalize() {
+	sort.Slice(t.Bands, func(i, j int) bool {
+		return t.Bands[i].From.LessThan(t.Bands[j].From)
+	})
+}
+
+// Validate checks that the bands start at zero, don't overlap and all have a positive increment.
+// Bands must be normalized first
+func (t *IncrementTable) Validate() error {
+	if len(t.Bands) == 0 {
+		return fmt.Errorf("increment table needs at least one band: %w", constants.ErrInvalidInput)
+	}
+	if !t.Bands[0].From.IsZero() {
+		return fmt.Errorf("the first increment band must start at 0: %w", constants.ErrInvalidInput)
+	}
+	for i, band := range t.Bands {
+		if !band.Increment.IsPositive() {
+			return fmt.Errorf("increments must be greater than zero: %w", constants.ErrInvalidInput)
+		}
+		if i > 0 && !band.From.GreaterThan(t.Bands[i-1].From) {
+			return fmt.Errorf("increment bands must have distinct starting prices: %w", constants.ErrInvalidInput)
+		}
+	}
+	return nil
+}
+
+// IncrementAt returns the increment that applies on top of price
+func (t *IncrementTable) IncrementAt(price decimal.Decimal) decimal.Decimal {
+	increment := t.Bands[0].Increment
+	for _, band := range t.Bands {
+		if price.LessThan(band.From) {
+			break
+		}
+		increment = band.Increment
+	}
+	return increment
+}
+
+// SmallestIncrement is the lowest increment anywhere in the table
+func (t *IncrementTable) SmallestIncrement() decimal.Decimal {
+	smallest := t.Bands[0].Increment
+	for _, band := range t.Bands[1:] {
+		smallest = decimal.Min(smallest, band.Increment)
+	}
+	return smallest
+}
+
+// Increment tables are managed by admins and read by everyone, so every method uses the
+// service client
+type IncrementTableInterface interface {
+	CreateIncrementTable(ctx context.Context, table *IncrementTable) (*IncrementTable, error)
+	GetIncrementTableById(ctx context.Context, tableID uuid.UUID) (*IncrementTable, error)
+	GetIncrementTableForCategory(ctx context.Context, category string) (*IncrementTable, error)
+	ListIncrementTables(ctx context.Context) ([]*IncrementTable, error)
+}
+
+func (sr *SupabaseRepo) CreateIncrementTable(ctx context.Context, table *IncrementTable) (*IncrementTable, error) {
+	if sr.serviceClient == nil {
+		return nil, constants.ErrNoClient
+	}
+
+	byteData, _, err := sr.serviceClient.From(string(constants.IncrementTableTable)).Insert(table, false, "", "", "exact").Execute()
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert increment table: %w", err)
+	}
+
+	var t []IncrementTable
+	if err := json.Unmarshal(byteData, &t); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal increment table: %w", err)
+	}
+	if len(t) == 0 {
+		return nil, fmt.Errorf("failed to insert increment table: no data returned")
+	}
+	return &t[0], nil
+}
+
+func (sr *SupabaseRepo) GetIncrementTableById(ctx context.Context, tableID uuid.UUID) (*IncrementTable, error) {
+	if sr.serviceClient == nil {
+		return nil, constants.ErrNoClient
+	}
+
+	byteData, _, err := sr.serviceClient.From(string(constants.IncrementTableTable)).
+		Select("*", "exact", false).
+		Eq("id", tableID.String()).
+		Execute()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get increment table: %w", err)
+	}
+
+	var t []IncrementTable
+	if err := json.Unmarshal(byteData, &t); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal increment table: %w", err)
+	}
+	if len(t) == 0 {
+		return nil, constants.ErrNotFound
+	}
+	t[0].Normalize()
+	return &t[0], nil
+}
+
+// GetIncrementTableForCategory returns the most recent table for the category, falling back to
+// the most recent global table. It returns ErrNoData when neither exists
+func (sr *SupabaseRepo) GetIncrementTableForCategory(ctx context.Context, category string) (*IncrementTable, error) {
+	if sr.serviceClient == nil {
+		return nil, constants.ErrNoClient
+	}
+
+	query := sr.serviceClient.From(string(constants.IncrementTableTable)).Select("*", "exact", false)
+	if category != "" {
+		category = strings.ReplaceAll(category, `"`, "")
+		query = query.Or(`category.ilike."`+category+`",category.is.null`, "")
+	} else {
+		query = query.Is("category", "null")
+	}
+
+	byteData, _, err := query.
+		Order("category", &postgrest.OrderOpts{Ascending: true, NullsFirst: false}).
+		Order("created_at", &postgrest.OrderOpts{Ascending: false}).
+		Limit(1, "").
+		Execute()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get increment table: %w", err)
+	}
+
+	var t []IncrementTable
+	if err := json.Unmarshal(byteData, &t); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal increment table: %w", err)
+	}
+	if len(t) == 0 {
+		return nil, constants.ErrNoData
+	}
+	t[0].Normalize()
+	return &t[0], nil
+}
+
+func (sr *SupabaseRepo) ListIncrementTables(ctx context.Context) ([]*IncrementTable, error) {
+	if sr.serviceClient == nil {
+		return nil, constants.ErrNoClient
+	}
+
+	byteData, _, err := sr.serviceClient.From(string(constants.IncrementTableTable)).
+		Select("*", "exact", false).
+		Order("created_at", &postgrest.OrderOpts{Ascending: false}).
+		Execute()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list increment tables: %w", err)
+	}
+
+	var t []*IncrementTable
+	if err := json.Unmarshal(byteData, &t); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal increment tables: %w", err)
+	}
+	for _, table := range t {
+		table.Normalize()
+	}
+	return t, nil
+}
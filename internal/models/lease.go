@@ -0,0 +1,100 @@
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/joshua-takyi/auction/internal/constants"
+)
+
+// LeaseInterface grants a named lease to one holder at a time. A lease that isn't renewed
+// before its ttl runs out is free for anyone to take, which is how a dead holder is replaced
+type LeaseInterface interface {
+	// AcquireLease takes the lease or renews it for its current holder, and reports whether
+	// holder has it afterwards
+	AcquireLease(ctx context.Context, name, holder string, ttl time.Duration) (bool, error)
+	ReleaseLease(ctx context.Context, name, holder string) error
+}
+
+// AcquireLease calls the acquire_lease rpc. PostgREST hands every request its own pooled
+// connection, so a session advisory lock can't be held from one request to the next. Instead the
+// function takes a transaction advisory lock on the lease name to serialise claimants, then
+// claims the leases row when it is free, expired or already held by holder
+func (sr *SupabaseRepo) AcquireLease(ctx context.Context, name, holder string, ttl time.Duration) (bool, error) {
+	if sr.serviceClient == nil {
+		return false, constants.ErrNoClient
+	}
+
+	params := map[string]any{
+		"p_name":        name,
+		"p_holder":      holder,
+		"p_ttl_seconds": int(ttl.Seconds()),
+	}
+	res, _, err := sr.serviceClient.From("rpc/acquire_lease").Insert(params, false, "", "", "exact").Execute()
+	if err != nil {
+		return false, fmt.Errorf("failed to call acquire_lease rpc: %w", err)
+	}
+
+	var acquired bool
+	if err := json.Unmarshal(res, &acquired); err != nil {
+		return false, fmt.Errorf("failed to unmarshal rpc result: %w", err)
+	}
+	return acquired, nil
+}
+
+// ReleaseLease calls the release_lease rpc, which frees the lease only if holder still has it
+func (sr *SupabaseRepo) ReleaseLease(ctx context.Context, name, holder string) error {
+	if sr.serviceClient == nil {
+		return constants.ErrNoClient
+	}
+
+	params := map[string]any{
+		"p_name":   name,
+		"p_holder": holder,
+	}
+	if _, _, err := sr.serviceClient.From("rpc/release_lease").Insert(params, false, "", "", "exact").Execute(); err != nil {
+		return fmt.Errorf("failed to call release_lease rpc: %w", err)
+	}
+	return nil
+}
+
+type memoryLease struct {
+	holder    string
+	expiresAt time.Time
+}
+
+// MemoryLeases is a LeaseInterface for a single process, for tests and deployments that only
+// run one instance
+type MemoryLeases struct {
+	mu     sync.Mutex
+	leases map[string]memoryLease
+}
+
+func NewMemoryLeases() *MemoryLeases {
+	return &MemoryLeases{leases: make(map[string]memoryLease)}
+}
+
+func (m *MemoryLeases) AcquireLease(ctx context.Context, name, holder string, ttl time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	if lease, ok := m.leases[name]; ok && lease.holder != holder && now.Before(lease.expiresAt) {
+		return false, nil
+	}
+	m.leases[name] = memoryLease{holder: holder, expiresAt: now.Add(ttl)}
+	return true, nil
+}
+
+func (m *MemoryLeases) ReleaseLease(ctx context.Context, name, holder string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if lease, ok := m.leases[name]; ok && lease.holder == holder {
+		delete(m.leases, name)
+	}
+	return nil
+}
@@ -0,0 +1,256 @@
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/joshua-takyi/auction/internal/constants"
+	"github.com/shopspring/decimal"
+	"github.com/supabase-community/postgrest-go"
+)
+
+// LotBid is a bidder's standing bid on a multi-unit auction: how many units they want and the
+// most they will pay for each. Revising it replaces both
+type LotBid struct {
+	ID        uuid.UUID       `db:"id" json:"id"`
+	AuctionID uuid.UUID       `db:"auction_id" json:"auction_id"`
+	BidderID  uuid.UUID       `db:"bidder_id" json:"bidder_id"`
+	Quantity  int             `db:"quantity" json:"quantity"`
+	UnitPrice decimal.Decimal `db:"unit_price" json:"unit_price"`
+	CreatedAt time.Time       `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time       `db:"updated_at" json:"updated_at"`
+}
+
+// ToBid presents a lot bid as a regular bid, with BidAmount holding the unit price
+func (b *LotBid) ToBid() *Bid {
+	return &Bid{
+		ID:        b.ID,
+		AuctionID: b.AuctionID,
+		BidBy:     b.BidderID,
+		BidAmount: b.UnitPrice,
+		Quantity:  b.Quantity,
+		BidAt:     b.UpdatedAt,
+		CreatedAt: b.CreatedAt,
+		UpdatedAt: b.UpdatedAt,
+	}
+}
+
+// LotAllocation records the units a winner of a multi-unit auction receives and the uniform
+// price they pay for each
+type LotAllocation struct {
+	ID        uuid.UUID       `db:"id" json:"id"`
+	AuctionID uuid.UUID       `db:"auction_id" json:"auction_id"`
+	BidderID  uuid.UUID       `db:"bidder_id" json:"bidder_id"`
+	Requested int             `db:"requested" json:"requested"`
+	Quantity  int             `db:"quantity" json:"quantity"`
+	UnitPrice decimal.Decimal `db:"unit_price" json:"unit_price"`
+	CreatedAt time.Time       `db:"created_at" json:"created_at"`
+}
+
+// IsPartial reports whether the winner got fewer units than they bid for
+func (a *LotAllocation) IsPartial() bool {
+	return a.Quantity < a.Requested
+}
+
+// SortLotBids orders bids the way AllocateLot expects them
+func SortLotBids(bids []*LotBid) {
+	sort.SliceStable(bids, func(i, j int) bool {
+		if !bids[i].UnitPrice.Equal(bids[j].UnitPrice) {
+			return bids[i].UnitPrice.GreaterThan(bids[j].UnitPrice)
+		}
+		return bids[i].UpdatedAt.Before(bids[j].UpdatedAt)
+	})
+}
+
+// AllocateLot fills quantity units from bids, which must be sorted highest unit price first and
+// earliest first among equal prices. Bids under minPrice (the start price or reserve) are
+// skipped. Every winner pays the same clearing price: the lowest winning unit price, but never
+// less than minPrice. The last winner may be only partly filled
+func AllocateLot(quantity int, bids []*LotBid, minPrice decimal.Decimal) ([]*LotAllocation, decimal.Decimal) {
+	var allocations []*LotAllocation
+	remaining := quantity
+	clearing := minPrice
+
+	for _, bid := range bids {
+		if remaining == 0 {
+			break
+		}
+		if bid.UnitPrice.LessThan(minPrice) {
+			break
+		}
+		units := min(bid.Quantity, remaining)
+		remaining -= units
+		clearing = bid.UnitPrice
+		allocations = append(allocations, &LotAllocation{
+			AuctionID: bid.AuctionID,
+			BidderID:  bid.BidderID,
+			Requested: bid.Quantity,
+			Quantity:  units,
+		})
+	}
+
+	clearing = decimal.Max(clearing, minPrice)
+	for _, allocation := range allocations {
+		allocation.UnitPrice = clearing
+	}
+	return allocations, clearing
+}
+
+// Lot bids are visible to everyone, but they are written on behalf of bidders after the service
+// has validated them and read by background jobs, so every method uses the service client
+type LotInterface interface {
+	SaveLotBid(ctx context.Context, bid, previous *LotBid) (*LotBid, error)
+	GetLotBid(ctx context.Context, auctionID, bidderID uuid.UUID) (*LotBid, error)
+	GetLotBids(ctx context.Context, auctionID uuid.UUID) ([]*LotBid, error)
+	CreateLotAllocations(ctx context.Context, allocations []*LotAllocation) error
+	GetLotAllocations(ctx context.Context, auctionID uuid.UUID) ([]*LotAllocation, error)
+}
+
+// SaveLotBid stores a bidder's first lot bid, or revises previous, the bid the service checked the
+// revision against. A revision only applies while the stored row still has previous's quantity
+// and unit price, and returns ErrNoData otherwise, so a concurrent revision from another instance
+// can't slip a reduction past those checks. A first bid is a plain insert for the same reason
+func (sr *SupabaseRepo) SaveLotBid(ctx context.Context, bid, previous *LotBid) (*LotBid, error) {
+	if sr.serviceClient == nil {
+		return nil, constants.ErrNoClient
+	}
+
+	// id and created_at are left to the table defaults so a revision keeps the original row
+	row := map[string]any{
+		"auction_id": bid.AuctionID,
+		"bidder_id":  bid.BidderID,
+		"quantity":   bid.Quantity,
+		"unit_price": bid.UnitPrice,
+		"updated_at": bid.UpdatedAt,
+	}
+
+	var byteData []byte
+	var err error
+	if previous == nil {
+		byteData, _, err = sr.serviceClient.From(string(constants.LotBidTable)).Insert(row, false, "", "", "exact").Execute()
+	} else {
+		byteData, _, err = sr.serviceClient.From(string(constants.LotBidTable)).
+			Update(row, "", "exact").
+			Eq("auction_id", bid.AuctionID.String()).
+			Eq("bidder_id", bid.BidderID.String()).
+			Eq("quantity", strconv.Itoa(previous.Quantity)).
+			Eq("unit_price", previous.UnitPrice.String()).
+			Execute()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to save lot bid: %w", err)
+	}
+
+	var b []LotBid
+	if err := json.Unmarshal(byteData, &b); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal lot bid: %w", err)
+	}
+	if len(b) == 0 {
+		return nil, constants.ErrNoData
+	}
+	return &b[0], nil
+}
+
+func (sr *SupabaseRepo) GetLotBid(ctx context.Context, auctionID, bidderID uuid.UUID) (*LotBid, error) {
+	if sr.serviceClient == nil {
+		return nil, constants.ErrNoClient
+	}
+
+	byteData, _, err := sr.serviceClient.From(string(constants.LotBidTable)).
+		Select("*", "exact", false).
+		Eq("auction_id", auctionID.String()).
+		Eq("bidder_id", bidderID.String()).
+		Execute()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get lot bid: %w", err)
+	}
+
+	var b []LotBid
+	if err := json.Unmarshal(byteData, &b); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal lot bid: %w", err)
+	}
+	if len(b) == 0 {
+		return nil, constants.ErrNoData
+	}
+	return &b[0], nil
+}
+
+// GetLotBids returns every lot bid on an auction, highest unit price first and earliest first
+// among equal prices, which is the order AllocateLot expects
+func (sr *SupabaseRepo) GetLotBids(ctx context.Context, auctionID uuid.UUID) ([]*LotBid, error) {
+	if sr.serviceClient == nil {
+		return nil, constants.ErrNoClient
+	}
+
+	byteData, _, err := sr.serviceClient.From(string(constants.LotBidTable)).
+		Select("*", "exact", false).
+		Eq("auction_id", auctionID.String()).
+		Order("unit_price", &postgrest.OrderOpts{Ascending: false}).
+		Order("updated_at", &postgrest.OrderOpts{Ascending: true}).
+		Execute()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load lot bids: %w", err)
+	}
+
+	var res []*LotBid
+	if err := json.Unmarshal(byteData, &res); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal lot bids: %w", err)
+	}
+	return res, nil
+}
+
+// CreateLotAllocations stores the winners of a multi-unit auction. Allocations are unique per
+// auction and bidder, so writing the same result twice leaves a single set
+func (sr *SupabaseRepo) CreateLotAllocations(ctx context.Context, allocations []*LotAllocation) error {
+	if sr.serviceClient == nil {
+		return constants.ErrNoClient
+	}
+	if len(allocations) == 0 {
+		return nil
+	}
+
+	// id and created_at are left to the table defaults, as with lot bids, so a settlement that
+	// runs twice keeps the rows it wrote the first time
+	rows := make([]map[string]any, 0, len(allocations))
+	for _, allocation := range allocations {
+		rows = append(rows, map[string]any{
+			"auction_id": allocation.AuctionID,
+			"bidder_id":  allocation.BidderID,
+			"requested":  allocation.Requested,
+			"quantity":   allocation.Quantity,
+			"unit_price": allocation.UnitPrice,
+		})
+	}
+
+	_, _, err := sr.serviceClient.From(string(constants.LotAllocationTable)).Upsert(rows, "auction_id,bidder_id", "", "exact").Execute()
+	if err != nil {
+		return fmt.Errorf("failed to store lot allocations: %w", err)
+	}
+	return nil
+}
+
+func (sr *SupabaseRepo) GetLotAllocations(ctx context.Context, auctionID uuid.UUID) ([]*LotAllocation, error) {
+	if sr.serviceClient == nil {
+		return nil, constants.ErrNoClient
+	}
+
+	byteData, _, err := sr.serviceClient.From(string(constants.LotAllocationTable)).
+		Select("*", "exact", false).
+		Eq("auction_id", auctionID.String()).
+		Order("quantity", &postgrest.OrderOpts{Ascending: false}).
+		Execute()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load lot allocations: %w", err)
+	}
+
+	var res []*LotAllocation
+	if err := json.Unmarshal(byteData, &res); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal lot allocations: %w", err)
+	}
+	return res, nil
+}
@@ -0,0 +1,94 @@
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/joshua-takyi/auction/internal/constants"
+	"github.com/shopspring/decimal"
+	"github.com/supabase-community/postgrest-go"
+	"github.com/supabase-community/supabase-go"
+)
+
+// MaxBid is a bidder's secret ceiling for proxy bidding on an auction.
+// UpdatedAt records when the current maximum was set and is used to break ties.
+type MaxBid struct {
+	ID        uuid.UUID       `db:"id" json:"id"`
+	AuctionID uuid.UUID       `db:"auction_id" json:"auction_id"`
+	BidderID  uuid.UUID       `db:"bidder_id" json:"bidder_id"`
+	MaxAmount decimal.Decimal `db:"max_amount" json:"max_amount"`
+	CreatedAt time.Time       `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time       `db:"updated_at" json:"updated_at"`
+}
+
+type MaxBidInterface interface {
+	UpsertMaxBid(ctx context.Context, maxBid *MaxBid, accessToken string) (*MaxBid, error)
+	GetMaxBids(ctx context.Context, auctionID uuid.UUID) ([]*MaxBid, error)
+}
+
+func (sr *SupabaseRepo) UpsertMaxBid(ctx context.Context, maxBid *MaxBid, accessToken string) (*MaxBid, error) {
+	var client *supabase.Client
+	var err error
+
+	if accessToken == "" {
+		if sr.serviceClient == nil {
+			return nil, constants.ErrNoClient
+		}
+		client = sr.serviceClient
+	} else {
+		client, err = sr.GetAuthenticatedClient(accessToken)
+		if err != nil {
+			return nil, constants.ErrNoClient
+		}
+	}
+
+	// id and created_at are left to the table defaults so raising a maximum keeps the original row
+	row := map[string]any{
+		"auction_id": maxBid.AuctionID,
+		"bidder_id":  maxBid.BidderID,
+		"max_amount": maxBid.MaxAmount,
+		"updated_at": maxBid.UpdatedAt,
+	}
+
+	byteData, _, err := client.From(string(constants.MaxBidTable)).Upsert(row, "auction_id,bidder_id", "", "exact").Execute()
+	if err != nil {
+		return nil, fmt.Errorf("failed to upsert max bid: %w", err)
+	}
+
+	var m []MaxBid
+	if err := json.Unmarshal(byteData, &m); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal max bid: %w", err)
+	}
+	if len(m) == 0 {
+		return nil, fmt.Errorf("failed to upsert max bid: no data returned")
+	}
+	return &m[0], nil
+}
+
+// GetMaxBids returns every proxy maximum on an auction, strongest first.
+// Maximums are private to their owners, so this always reads with the service client.
+func (sr *SupabaseRepo) GetMaxBids(ctx context.Context, auctionID uuid.UUID) ([]*MaxBid, error) {
+	client := sr.supabase
+	if sr.serviceClient != nil {
+		client = sr.serviceClient
+	}
+
+	byteData, _, err := client.From(string(constants.MaxBidTable)).
+		Select("*", "exact", false).
+		Eq("auction_id", auctionID.String()).
+		Order("max_amount", &postgrest.OrderOpts{Ascending: false}).
+		Order("updated_at", &postgrest.OrderOpts{Ascending: true}).
+		Execute()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load max bids: %w", err)
+	}
+
+	var m []*MaxBid
+	if err := json.Unmarshal(byteData, &m); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal max bids: %w", err)
+	}
+	return m, nil
+}
@@ -0,0 +1,135 @@
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/joshua-takyi/auction/internal/constants"
+	"github.com/shopspring/decimal"
+	"github.com/supabase-community/postgrest-go"
+)
+
+type Payment struct {
+	ID               uuid.UUID       `db:"id" json:"id"`
+	AuctionID        uuid.UUID       `db:"auction_id" json:"auction_id"`
+	UserID           uuid.UUID       `db:"user_id" json:"user_id"`
+	Reference        string          `db:"reference" json:"reference"`
+	Amount           decimal.Decimal `db:"amount" json:"amount"`
+	Currency         string          `db:"currency" json:"currency"`
+	Status           string          `db:"status" json:"status"`
+	AuthorizationURL string          `db:"authorization_url" json:"authorization_url"`
+	PaidAt           *time.Time      `db:"paid_at" json:"paid_at"`
+	CreatedAt        time.Time       `db:"created_at" json:"created_at"`
+	UpdatedAt        time.Time       `db:"updated_at" json:"updated_at"`
+}
+
+// Payments are written by the server on behalf of the winner and by the Paystack webhook, so
+// every method uses the service client
+type PaymentInterface interface {
+	CreatePayment(ctx context.Context, payment *Payment) (*Payment, error)
+	GetPaymentByReference(ctx context.Context, reference string) (*Payment, error)
+	GetLatestPayment(ctx context.Context, auctionID, userID uuid.UUID) (*Payment, error)
+	UpdatePaymentStatus(ctx context.Context, reference, status string, paidAt *time.Time) (*Payment, error)
+}
+
+func (sr *SupabaseRepo) CreatePayment(ctx context.Context, payment *Payment) (*Payment, error) {
+	if sr.serviceClient == nil {
+		return nil, constants.ErrNoClient
+	}
+
+	byteData, _, err := sr.serviceClient.From(string(constants.PaymentTable)).Insert(payment, false, "", "", "exact").Execute()
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert payment: %w", err)
+	}
+
+	var p []Payment
+	if err := json.Unmarshal(byteData, &p); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal payment: %w", err)
+	}
+	if len(p) == 0 {
+		return nil, fmt.Errorf("failed to insert payment: no data returned")
+	}
+	return &p[0], nil
+}
+
+func (sr *SupabaseRepo) GetPaymentByReference(ctx context.Context, reference string) (*Payment, error) {
+	if sr.serviceClient == nil {
+		return nil, constants.ErrNoClient
+	}
+
+	byteData, _, err := sr.serviceClient.From(string(constants.PaymentTable)).
+		Select("*", "exact", false).
+		Eq("reference", reference).
+		Execute()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get payment: %w", err)
+	}
+
+	var p []Payment
+	if err := json.Unmarshal(byteData, &p); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal payment: %w", err)
+	}
+	if len(p) == 0 {
+		return nil, constants.ErrNotFound
+	}
+	return &p[0], nil
+}
+
+// GetLatestPayment returns the most recent payment attempt a user made for an auction
+func (sr *SupabaseRepo) GetLatestPayment(ctx context.Context, auctionID, userID uuid.UUID) (*Payment, error) {
+	if sr.serviceClient == nil {
+		return nil, constants.ErrNoClient
+	}
+
+	byteData, _, err := sr.serviceClient.From(string(constants.PaymentTable)).
+		Select("*", "exact", false).
+		Eq("auction_id", auctionID.String()).
+		Eq("user_id", userID.String()).
+		Order("created_at", &postgrest.OrderOpts{Ascending: false}).
+		Limit(1, "").
+		Execute()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get payment: %w", err)
+	}
+
+	var p []Payment
+	if err := json.Unmarshal(byteData, &p); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal payment: %w", err)
+	}
+	if len(p) == 0 {
+		return nil, constants.ErrNoData
+	}
+	return &p[0], nil
+}
+
+func (sr *SupabaseRepo) UpdatePaymentStatus(ctx context.Context, reference, status string, paidAt *time.Time) (*Payment, error) {
+	if sr.serviceClient == nil {
+		return nil, constants.ErrNoClient
+	}
+
+	update := map[string]any{
+		"status":     status,
+		"paid_at":    paidAt,
+		"updated_at": time.Now(),
+	}
+
+	byteData, _, err := sr.serviceClient.From(string(constants.PaymentTable)).
+		Update(update, "", "exact").
+		Eq("reference", reference).
+		Execute()
+	if err != nil {
+		return nil, fmt.Errorf("failed to update payment: %w", err)
+	}
+
+	var p []Payment
+	if err := json.Unmarshal(byteData, &p); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal payment: %w", err)
+	}
+	if len(p) == 0 {
+		return nil, constants.ErrNotFound
+	}
+	return &p[0], nil
+}
@@ -47,6 +47,7 @@ type ProductInterface interface {
 	DeleteProduct(ctx context.Context, accessToken string, productID uuid.UUID) error
 	GetProductWithAuction(ctx context.Context, productID uuid.UUID) (*ProductResponse, error)
 	GetProductsByOwner(ctx context.Context, accessToken string, ownerID uuid.UUID, limit, offset int) ([]*Product, int64, error)
+	UpdateProductStatus(ctx context.Context, productID uuid.UUID, status string) error
 }
 
 func (sr *SupabaseRepo) CreateProduct(ctx context.Context, product *Product, accessToken string, userID uuid.UUID) (*Product, error) {
@@ -227,3 +228,25 @@ func (sr *SupabaseRepo) GetProductsByOwner(ctx context.Context, accessToken stri
 
 	return p, count, nil
 }
+
+// UpdateProductStatus moves a product through its lifecycle. Status changes are made by the
+// system rather than the owner, so they always go through the service client
+func (sr *SupabaseRepo) UpdateProductStatus(ctx context.Context, productID uuid.UUID, status string) error {
+	if sr.serviceClient == nil {
+		return constants.ErrNoClient
+	}
+
+	update := map[string]any{
+		"status":     status,
+		"updated_at": time.Now(),
+	}
+
+	_, count, err := sr.serviceClient.From(string(constants.ProductTable)).Update(update, "", "exact").Eq("id", productID.String()).Execute()
+	if err != nil {
+		return fmt.Errorf("failed to update product status: %w", err)
+	}
+	if count == 0 {
+		return constants.ErrNotFound
+	}
+	return nil
+}
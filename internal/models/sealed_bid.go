@@ -0,0 +1,123 @@
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/joshua-takyi/auction/internal/constants"
+	"github.com/shopspring/decimal"
+	"github.com/supabase-community/postgrest-go"
+)
+
+// SealedBid is a bidder's single bid on a sealed-bid auction. Revising it replaces the amount
+// and bumps Revision; UpdatedAt records when the current amount was submitted
+type SealedBid struct {
+	ID        uuid.UUID       `db:"id" json:"id"`
+	AuctionID uuid.UUID       `db:"auction_id" json:"auction_id"`
+	BidderID  uuid.UUID       `db:"bidder_id" json:"bidder_id"`
+	Amount    decimal.Decimal `db:"amount" json:"amount"`
+	Revision  int             `db:"revision" json:"revision"`
+	CreatedAt time.Time       `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time       `db:"updated_at" json:"updated_at"`
+}
+
+// ToBid presents a sealed bid as a regular bid once the auction has closed
+func (b *SealedBid) ToBid() *Bid {
+	return &Bid{
+		ID:        b.ID,
+		AuctionID: b.AuctionID,
+		BidBy:     b.BidderID,
+		BidAmount: b.Amount,
+		BidAt:     b.UpdatedAt,
+		CreatedAt: b.CreatedAt,
+		UpdatedAt: b.UpdatedAt,
+	}
+}
+
+// Sealed bids must never be readable by other bidders before close, so they are only ever
+// accessed with the service client after the service layer has checked who is asking
+type SealedBidInterface interface {
+	UpsertSealedBid(ctx context.Context, bid *SealedBid) (*SealedBid, error)
+	GetSealedBid(ctx context.Context, auctionID, bidderID uuid.UUID) (*SealedBid, error)
+	GetSealedBids(ctx context.Context, auctionID uuid.UUID) ([]*SealedBid, error)
+}
+
+func (sr *SupabaseRepo) UpsertSealedBid(ctx context.Context, bid *SealedBid) (*SealedBid, error) {
+	if sr.serviceClient == nil {
+		return nil, constants.ErrNoClient
+	}
+
+	// id and created_at are left to the table defaults so a revision keeps the original row
+	row := map[string]any{
+		"auction_id": bid.AuctionID,
+		"bidder_id":  bid.BidderID,
+		"amount":     bid.Amount,
+		"revision":   bid.Revision,
+		"updated_at": bid.UpdatedAt,
+	}
+
+	byteData, _, err := sr.serviceClient.From(string(constants.SealedBidTable)).Upsert(row, "auction_id,bidder_id", "", "exact").Execute()
+	if err != nil {
+		return nil, fmt.Errorf("failed to upsert sealed bid: %w", err)
+	}
+
+	var b []SealedBid
+	if err := json.Unmarshal(byteData, &b); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal sealed bid: %w", err)
+	}
+	if len(b) == 0 {
+		return nil, fmt.Errorf("failed to upsert sealed bid: no data returned")
+	}
+	return &b[0], nil
+}
+
+func (sr *SupabaseRepo) GetSealedBid(ctx context.Context, auctionID, bidderID uuid.UUID) (*SealedBid, error) {
+	if sr.serviceClient == nil {
+		return nil, constants.ErrNoClient
+	}
+
+	byteData, _, err := sr.serviceClient.From(string(constants.SealedBidTable)).
+		Select("*", "exact", false).
+		Eq("auction_id", auctionID.String()).
+		Eq("bidder_id", bidderID.String()).
+		Execute()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sealed bid: %w", err)
+	}
+
+	var b []SealedBid
+	if err := json.Unmarshal(byteData, &b); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal sealed bid: %w", err)
+	}
+	if len(b) == 0 {
+		return nil, constants.ErrNoData
+	}
+	return &b[0], nil
+}
+
+// GetSealedBids returns every sealed bid on an auction, highest first and earliest submitted
+// first among equal amounts, the same order GetAllBids uses
+func (sr *SupabaseRepo) GetSealedBids(ctx context.Context, auctionID uuid.UUID) ([]*SealedBid, error) {
+	if sr.serviceClient == nil {
+		return nil, constants.ErrNoClient
+	}
+
+	byteData, _, err := sr.serviceClient.From(string(constants.SealedBidTable)).
+		Select("*", "exact", false).
+		Eq("auction_id", auctionID.String()).
+		Order("amount", &postgrest.OrderOpts{Ascending: false}).
+		Order("updated_at", &postgrest.OrderOpts{Ascending: true}).
+		Execute()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load sealed bids: %w", err)
+	}
+
+	var res []*SealedBid
+	if err := json.Unmarshal(byteData, &res); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal sealed bids: %w", err)
+	}
+	return res, nil
+}
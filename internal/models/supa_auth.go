@@ -26,6 +26,21 @@ func (su *SupabaseRepo) GetAuthenticatedClient(accessToken string) (*supabase.Cl
 	return supabase.NewClient(su.url, su.anonKey, options)
 }
 
+// clientFor returns a client acting as the token's user, or the service client for an empty token
+func (sr *SupabaseRepo) clientFor(accessToken string) (*supabase.Client, error) {
+	if accessToken == "" {
+		if sr.serviceClient == nil {
+			return nil, constants.ErrNoClient
+		}
+		return sr.serviceClient, nil
+	}
+	client, err := sr.GetAuthenticatedClient(accessToken)
+	if err != nil {
+		return nil, constants.ErrNoClient
+	}
+	return client, nil
+}
+
 func (sr *SupabaseRepo) GetAuthenticatedUser(ctx context.Context, accessToken string) *supabase.Client {
 	if accessToken == "" {
 		if authClient, err := sr.GetAuthenticatedClient(accessToken); err == nil && authClient != nil {
@@ -0,0 +1,177 @@
+package payments
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultBaseURL is the live Paystack API. Tests and local setups point the client at a stand-in
+// server instead.
+const DefaultBaseURL = "https://api.paystack.co"
+
+var (
+	ErrInvalidSignature = errors.New("invalid paystack signature")
+	ErrRequestFailed    = errors.New("paystack request failed")
+)
+
+// Transaction statuses reported by Paystack
+const (
+	StatusSuccess   = "success"
+	StatusFailed    = "failed"
+	StatusAbandoned = "abandoned"
+)
+
+// EventChargeSuccess is the webhook event sent once a charge has gone through
+const EventChargeSuccess = "charge.success"
+
+// PaystackClient is a minimal client for the Paystack transaction API
+type PaystackClient struct {
+	secretKey  string
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewPaystackClient creates a client for the given secret key. An empty baseURL uses DefaultBaseURL
+func NewPaystackClient(secretKey, baseURL string) *PaystackClient {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	return &PaystackClient{
+		secretKey:  secretKey,
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+// InitializeRequest starts a transaction. Amount is in the currency's minor unit (pesewas, kobo)
+type InitializeRequest struct {
+	Email       string         `json:"email"`
+	Amount      int64          `json:"amount"`
+	Reference   string         `json:"reference"`
+	Currency    string         `json:"currency,omitempty"`
+	CallbackURL string         `json:"callback_url,omitempty"`
+	Metadata    map[string]any `json:"metadata,omitempty"`
+}
+
+type InitializeResponse struct {
+	AuthorizationURL string `json:"authorization_url"`
+	AccessCode       string `json:"access_code"`
+	Reference        string `json:"reference"`
+}
+
+type Transaction struct {
+	ID        int64           `json:"id"`
+	Status    string          `json:"status"`
+	Reference string          `json:"reference"`
+	Amount    int64           `json:"amount"`
+	Currency  string          `json:"currency"`
+	PaidAt    *time.Time      `json:"paid_at"`
+	Metadata  json.RawMessage `json:"metadata"` // Paystack sends "" when no metadata was attached
+}
+
+// Matches reports whether the transaction charged exactly amount, in minor units, in currency.
+// Paystack leaves the currency out of some responses, in which case only the amount is compared
+func (t *Transaction) Matches(amount int64, currency string) bool {
+	return t.Amount == amount && (t.Currency == "" || strings.EqualFold(t.Currency, currency))
+}
+
+// WebhookEvent is the body Paystack posts to the webhook endpoint
+type WebhookEvent struct {
+	Event string      `json:"event"`
+	Data  Transaction `json:"data"`
+}
+
+func (c *PaystackClient) InitializeTransaction(ctx context.Context, req InitializeRequest) (*InitializeResponse, error) {
+	var res InitializeResponse
+	if err := c.do(ctx, http.MethodPost, "/transaction/initialize", req, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// VerifyTransaction asks Paystack for the authoritative state of a transaction
+func (c *PaystackClient) VerifyTransaction(ctx context.Context, reference string) (*Transaction, error) {
+	var res Transaction
+	if err := c.do(ctx, http.MethodGet, "/transaction/verify/"+url.PathEscape(reference), nil, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// VerifySignature checks the x-paystack-signature header, an HMAC-SHA512 of the raw body
+// keyed with the secret key
+func (c *PaystackClient) VerifySignature(body []byte, signature string) bool {
+	if signature == "" {
+		return false
+	}
+	mac := hmac.New(sha512.New, []byte(c.secretKey))
+	mac.Write(body)
+	expected := hex.EncodeToString(mac.Sum(nil))
+	return hmac.Equal([]byte(expected), []byte(strings.ToLower(signature)))
+}
+
+// ParseWebhook verifies the signature and decodes the event
+func (c *PaystackClient) ParseWebhook(body []byte, signature string) (*WebhookEvent, error) {
+	if !c.VerifySignature(body, signature) {
+		return nil, ErrInvalidSignature
+	}
+	var event WebhookEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal webhook event: %w", err)
+	}
+	return &event, nil
+}
+
+// do sends a request and decodes the data field of Paystack's {status, message, data} envelope into out
+func (c *PaystackClient) do(ctx context.Context, method, path string, body any, out any) error {
+	var reader *bytes.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal paystack request: %w", err)
+		}
+		reader = bytes.NewReader(payload)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return fmt.Errorf("failed to build paystack request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.secretKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrRequestFailed, err)
+	}
+	defer resp.Body.Close()
+
+	var envelope struct {
+		Status  bool            `json:"status"`
+		Message string          `json:"message"`
+		Data    json.RawMessage `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		return fmt.Errorf("%w: unreadable response (status %d)", ErrRequestFailed, resp.StatusCode)
+	}
+	if resp.StatusCode >= http.StatusBadRequest || !envelope.Status {
+		return fmt.Errorf("%w: %s", ErrRequestFailed, envelope.Message)
+	}
+
+	if err := json.Unmarshal(envelope.Data, out); err != nil {
+		return fmt.Errorf("failed to unmarshal paystack data: %w", err)
+	}
+	return nil
+}
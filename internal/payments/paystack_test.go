@@ -0,0 +1,189 @@
+package payments
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const testSecret = "sk_test_secret"
+
+// newTestServer stands in for the Paystack API and returns a client pointed at it. Verify requests
+// are answered from transactions by reference; the "fail-" references get an error response instead
+func newTestServer(t *testing.T, transactions map[string]Transaction) *PaystackClient {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /transaction/initialize", func(w http.ResponseWriter, r *http.Request) {
+		var req InitializeRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decode initialize request: %v", err)
+		}
+		writeEnvelope(t, w, http.StatusOK, true, "Authorization URL created", InitializeResponse{
+			AuthorizationURL: "https://checkout.example/" + req.Reference,
+			AccessCode:       "access-" + req.Reference,
+			Reference:        req.Reference,
+		})
+	})
+	mux.HandleFunc("GET /transaction/verify/{reference}", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer "+testSecret {
+			writeEnvelope(t, w, http.StatusUnauthorized, false, "Invalid key", nil)
+			return
+		}
+		reference := r.PathValue("reference")
+		switch reference {
+		case "fail-500":
+			writeEnvelope(t, w, http.StatusInternalServerError, false, "Internal error", nil)
+			return
+		case "fail-bad-gateway":
+			w.WriteHeader(http.StatusBadGateway)
+			w.Write([]byte("<html>Bad Gateway</html>"))
+			return
+		}
+		tx, ok := transactions[reference]
+		if !ok {
+			writeEnvelope(t, w, http.StatusBadRequest, false, "Transaction reference not found", nil)
+			return
+		}
+		writeEnvelope(t, w, http.StatusOK, true, "Verification successful", tx)
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return NewPaystackClient(testSecret, server.URL)
+}
+
+func writeEnvelope(t *testing.T, w http.ResponseWriter, status int, ok bool, message string, data any) {
+	t.Helper()
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(map[string]any{"status": ok, "message": message, "data": data}); err != nil {
+		t.Errorf("encode response: %v", err)
+	}
+}
+
+func sign(secret string, body []byte) string {
+	mac := hmac.New(sha512.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestInitializeTransaction(t *testing.T) {
+	client := newTestServer(t, nil)
+
+	res, err := client.InitializeTransaction(context.Background(), InitializeRequest{
+		Email:     "bidder@example.com",
+		Amount:    150000,
+		Reference: "ref-1",
+		Currency:  "GHS",
+	})
+	if err != nil {
+		t.Fatalf("InitializeTransaction: %v", err)
+	}
+	if res.Reference != "ref-1" || res.AuthorizationURL != "https://checkout.example/ref-1" {
+		t.Errorf("unexpected response %+v", res)
+	}
+}
+
+func TestParseWebhookSignature(t *testing.T) {
+	client := NewPaystackClient(testSecret, "")
+	body := []byte(`{"event":"charge.success","data":{"reference":"ref-1","status":"success","amount":150000,"currency":"GHS"}}`)
+
+	tests := []struct {
+		name      string
+		signature string
+		wantErr   error
+	}{
+		{name: "valid", signature: sign(testSecret, body)},
+		{name: "valid upper case", signature: strings.ToUpper(sign(testSecret, body))},
+		{name: "wrong secret", signature: sign("sk_test_other", body), wantErr: ErrInvalidSignature},
+		{name: "tampered body", signature: sign(testSecret, append([]byte(" "), body...)), wantErr: ErrInvalidSignature},
+		{name: "missing", signature: "", wantErr: ErrInvalidSignature},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event, err := client.ParseWebhook(body, tt.signature)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseWebhook: %v", err)
+			}
+			if event.Event != EventChargeSuccess || event.Data.Reference != "ref-1" {
+				t.Errorf("unexpected event %+v", event)
+			}
+		})
+	}
+}
+
+func TestVerifyTransactionMatches(t *testing.T) {
+	client := newTestServer(t, map[string]Transaction{
+		"ref-ok":          {Reference: "ref-ok", Status: StatusSuccess, Amount: 150000, Currency: "GHS"},
+		"ref-underpaid":   {Reference: "ref-underpaid", Status: StatusSuccess, Amount: 100, Currency: "GHS"},
+		"ref-currency":    {Reference: "ref-currency", Status: StatusSuccess, Amount: 150000, Currency: "NGN"},
+		"ref-no-currency": {Reference: "ref-no-currency", Status: StatusSuccess, Amount: 150000},
+		"ref-lower-case":  {Reference: "ref-lower-case", Status: StatusSuccess, Amount: 150000, Currency: "ghs"},
+		"ref-overpaid":    {Reference: "ref-overpaid", Status: StatusSuccess, Amount: 150001, Currency: "GHS"},
+	})
+
+	tests := []struct {
+		reference string
+		want      bool
+	}{
+		{reference: "ref-ok", want: true},
+		{reference: "ref-underpaid", want: false},
+		{reference: "ref-overpaid", want: false},
+		{reference: "ref-currency", want: false},
+		{reference: "ref-no-currency", want: true},
+		{reference: "ref-lower-case", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.reference, func(t *testing.T) {
+			tx, err := client.VerifyTransaction(context.Background(), tt.reference)
+			if err != nil {
+				t.Fatalf("VerifyTransaction: %v", err)
+			}
+			if tx.Reference != tt.reference || tx.Status != StatusSuccess {
+				t.Fatalf("unexpected transaction %+v", tx)
+			}
+			if got := tx.Matches(150000, "GHS"); got != tt.want {
+				t.Errorf("Matches = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyTransactionFailures(t *testing.T) {
+	client := newTestServer(t, nil)
+
+	tests := []struct {
+		name      string
+		client    *PaystackClient
+		reference string
+	}{
+		{name: "server error", client: client, reference: "fail-500"},
+		{name: "non-json error page", client: client, reference: "fail-bad-gateway"},
+		{name: "unknown reference", client: client, reference: "ref-missing"},
+		{name: "wrong secret", client: NewPaystackClient("sk_test_other", client.baseURL), reference: "ref-missing"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx, err := tt.client.VerifyTransaction(context.Background(), tt.reference)
+			if !errors.Is(err, ErrRequestFailed) {
+				t.Fatalf("expected ErrRequestFailed, got %v", err)
+			}
+			if tx != nil {
+				t.Errorf("expected no transaction, got %+v", tx)
+			}
+		})
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/joshua-takyi/auction/internal/handlers"
 	"github.com/joshua-takyi/auction/internal/middleware"
 	"github.com/joshua-takyi/auction/internal/utils"
+	"github.com/joshua-takyi/auction/internal/websockets"
 )
 
 func SetupRoutes(c *container.Container, cfg *config.Config) *gin.Engine {
@@ -53,12 +54,15 @@ func SetupRoutes(c *container.Container, cfg *config.Config) *gin.Engine {
 		v1.POST("/users", handlers.CreateUserHandler(c.UserService, logger))
 		v1.POST("/users/login", handlers.AuthenticateUserHandler(c.UserService, logger, c.IsProduction))
 		v1.POST("/auth/refresh", handlers.RefreshToken(c.UserService, c.IsProduction))
-		v1.GET("/auctions", handlers.ListAuctionsHandler(c.AuctionService))
-		v1.GET("/auctions/search", handlers.SearchAuctionsHandler(c.AuctionService))
-		v1.GET("/auctions/filter", handlers.FilterAuctionsHandler(c.AuctionService))
-		v1.GET("/auctions/:id", handlers.GetAuctionByIdHandler(c.AuctionService))
-		v1.GET("/products/auction/:id", handlers.GetProductWithAuctionHandler(c.ProductService))
-		v1.GET("/products/auction/recommendations", handlers.RecommendationHandler(c.AuctionService))
+		v1.GET("/auctions", middleware.OptionalAuthMiddleware(c.JWTManager, c.UserService), handlers.ListAuctionsHandler(c.AuctionService))
+		v1.GET("/auctions/search", middleware.OptionalAuthMiddleware(c.JWTManager, c.UserService), handlers.SearchAuctionsHandler(c.AuctionService))
+		v1.GET("/auctions/filter", middleware.OptionalAuthMiddleware(c.JWTManager, c.UserService), handlers.FilterAuctionsHandler(c.AuctionService))
+		v1.GET("/auctions/:id", middleware.OptionalAuthMiddleware(c.JWTManager, c.UserService), handlers.GetAuctionByIdHandler(c.AuctionService))
+		v1.GET("/auctions/:id/allocations", handlers.GetLotAllocationsHandler(c.AuctionService))
+		v1.GET("/products/auction/:id", middleware.OptionalAuthMiddleware(c.JWTManager, c.UserService), handlers.GetProductWithAuctionHandler(c.ProductService))
+		v1.GET("/products/auction/recommendations", middleware.OptionalAuthMiddleware(c.JWTManager, c.UserService), handlers.RecommendationHandler(c.AuctionService))
+		v1.POST("/payments/paystack/webhook", handlers.PaystackWebhookHandler(c.PaymentService))
+		v1.GET("/increment-tables", handlers.ListIncrementTablesHandler(c.IncrementService))
 
 		// --- Protected Routes (Require Auth) ---
 
@@ -88,21 +92,35 @@ func SetupRoutes(c *container.Container, cfg *config.Config) *gin.Engine {
 		auctionRoutes := protected.Group("/auctions")
 		{
 			auctionRoutes.POST("/:id", handlers.CreateAuctionHandler(c.AuctionService))
+			auctionRoutes.PATCH("/:id", handlers.UpdateAuctionHandler(c.AuctionService))
+			auctionRoutes.POST("/:id/cancel", handlers.CancelAuctionHandler(c.AuctionService))
 			auctionRoutes.DELETE("/:id", handlers.DeleteAuctionHandler(c.AuctionService))
 			auctionRoutes.POST("/:id/bid", handlers.PlaceBidHandler(c.BidService))
+			auctionRoutes.POST("/:id/buy-now", handlers.BuyNowHandler(c.AuctionService))
+			auctionRoutes.POST("/:id/accept", handlers.AcceptDutchAuctionHandler(c.DutchService))
+			auctionRoutes.POST("/:id/checkout", handlers.CheckoutHandler(c.PaymentService))
 			auctionRoutes.GET("/:id/bids", handlers.GetBids(c.BidService))
 			auctionRoutes.GET("/user/bids", handlers.GetUserAuctionWithBidHandler(c.BidService))
 			auctionRoutes.GET("/user", handlers.GetUserAuctions(c.AuctionService))
 		}
 
+		// Increment table Protected Routes (admin only)
+		protected.POST("/increment-tables", handlers.CreateIncrementTableHandler(c.IncrementService))
+
 		// Ticket generation for WebSockets
 		protected.POST("/ws/ticket", handlers.CreateWSTicketHandler(c.WSManager))
 
 		// --- WebSocket Route ---
 		// This route handles the upgrade. Authentication is handled via the ticket in the query param.
+		c.WSManager.HandleCommand(websockets.CmdPlaceBid, handlers.PlaceBidCommand(c.BidService))
 		v1.GET("/ws/auctions/:id", func(ctx *gin.Context) {
 			c.WSManager.Run(ctx)
 		})
+		// One connection per user: it carries the user's own notifications and subscribes to
+		// auction rooms with commands
+		v1.GET("/ws", func(ctx *gin.Context) {
+			c.WSManager.Run(ctx)
+		})
 	}
 
 	return r
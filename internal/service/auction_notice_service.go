@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/joshua-takyi/auction/internal/constants"
+	"github.com/joshua-takyi/auction/internal/models"
+)
+
+// noticeBatchSize caps how many auctions a single worker tick sends ending-soon notices for
+const noticeBatchSize = 100
+
+// AuctionNoticeService tells people when an auction opens, is about to close and has closed.
+// Started and ended notices follow the state machine's events, which only fire once per
+// transition. Ending-soon notices are timed by the scheduler, with the worker's tick as a safety
+// net; they are counted on the auction and the count is claimed before sending, so neither the
+// two paths, a second instance nor a restart repeats one
+type AuctionNoticeService struct {
+	auctionRepo   models.AuctionInterface
+	bidRepo       models.BidInterface
+	sealedBidRepo models.SealedBidInterface
+	lotRepo       models.LotInterface
+	notifService  *NotificationService
+	logger        *slog.Logger
+	endingNotices []time.Duration // time before the end, longest first
+}
+
+func NewAuctionNoticeService(auctionRepo models.AuctionInterface, bidRepo models.BidInterface, sealedBidRepo models.SealedBidInterface, lotRepo models.LotInterface, stateMachine *AuctionStateMachine, scheduler *AuctionScheduler, notifService *NotificationService, logger *slog.Logger, endingNotices []time.Duration) *AuctionNoticeService {
+	s := &AuctionNoticeService{
+		auctionRepo:   auctionRepo,
+		bidRepo:       bidRepo,
+		sealedBidRepo: sealedBidRepo,
+		lotRepo:       lotRepo,
+		notifService:  notifService,
+		logger:        logger,
+		endingNotices: endingNotices,
+	}
+	stateMachine.Subscribe(s.handleAuctionEvent)
+	scheduler.HandleEndingNotices(endingNotices, s.sendEndingSoon)
+	return s
+}
+
+func (s *AuctionNoticeService) handleAuctionEvent(ctx context.Context, event AuctionEvent) {
+	auction := event.Auction
+	switch event.Transition.ToStatus {
+	case constants.AuctionLive:
+		s.notifService.NotifyAuctionStarted(auction.RoomID.String(), auction.ID.String(), auction.EndTime)
+	case constants.AuctionEnded:
+		s.notifService.NotifyAuctionEnded(auction.RoomID.String(), auction.ID.String())
+	}
+}
+
+// SendEndingSoonNotices sends the ending-soon notices that have come due and the scheduler
+// missed, and returns how many auctions it sent one for
+func (s *AuctionNoticeService) SendEndingSoonNotices(ctx context.Context) (int, error) {
+	if len(s.endingNotices) == 0 {
+		return 0, nil
+	}
+
+	now := time.Now()
+	auctions, err := s.auctionRepo.GetEndingAuctions(ctx, now.Add(s.endingNotices[0]), len(s.endingNotices), noticeBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, auction := range auctions {
+		ok, err := s.sendEndingSoon(ctx, auction, now)
+		if err != nil {
+			if err != constants.ErrNoData {
+				s.logger.Error("Failed to send ending soon notice", "auction_id", auction.ID, "error", err)
+			}
+			continue
+		}
+		if ok {
+			sent++
+		}
+	}
+	return sent, nil
+}
+
+// sendEndingSoon sends at most one notice per call: the latest one that is due. Notices that were
+// missed (e.g. while the worker was down) are skipped rather than sent in a burst. On success the
+// new count is recorded on auction
+func (s *AuctionNoticeService) sendEndingSoon(ctx context.Context, auction *models.AuctionResponse, now time.Time) (bool, error) {
+	if !now.Before(auction.EndTime) {
+		return false, nil
+	}
+	due := auction.EndingNoticesSent
+	for i := auction.EndingNoticesSent; i < len(s.endingNotices); i++ {
+		if now.Before(auction.EndTime.Add(-s.endingNotices[i])) {
+			break
+		}
+		due = i + 1
+	}
+	if due == auction.EndingNoticesSent {
+		return false, nil
+	}
+
+	// Record first so a second worker can't send the same notice
+	if err := s.auctionRepo.MarkEndingNoticeSent(ctx, auction.ID, due, auction.EndingNoticesSent); err != nil {
+		return false, err
+	}
+	auction.EndingNoticesSent = due
+
+	auctionID := auction.ID.String()
+	title := auction.Product.Title
+	s.notifService.NotifyAuctionEndingSoon(auction.RoomID.String(), auctionID, title, auction.EndTime)
+
+	bids, err := auctionBids(ctx, s.bidRepo, s.sealedBidRepo, s.lotRepo, &auction.Auction)
+	if err != nil {
+		// The room has been told; bidders who aren't watching it just miss this one
+		s.logger.Error("Failed to load bidders for ending soon notice", "auction_id", auction.ID, "error", err)
+		return true, nil
+	}
+	notified := make(map[uuid.UUID]bool)
+	for _, bid := range bids {
+		if notified[bid.BidBy] {
+			continue
+		}
+		notified[bid.BidBy] = true
+		s.notifService.NotifyBidderAuctionEndingSoon(bid.BidBy.String(), auctionID, title, auction.EndTime)
+	}
+	return true, nil
+}
@@ -0,0 +1,390 @@
+package service
+
+import (
+	"container/heap"
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/joshua-takyi/auction/internal/constants"
+	"github.com/joshua-takyi/auction/internal/models"
+)
+
+const (
+	// scheduleLoadLimit caps how many auctions of each status are loaded per rebuild
+	scheduleLoadLimit = 1000
+	// scheduleHorizon is how far ahead a rebuild looks. Auctions further out are picked up by a
+	// later rebuild, or straight away when they are created or edited
+	scheduleHorizon = 24 * time.Hour
+	// scheduleRebuildInterval is how often the schedule is reloaded from the database
+	scheduleRebuildInterval = time.Hour
+	// scheduleFireTimeout bounds a single timed transition
+	scheduleFireTimeout = 10 * time.Second
+	// scheduleRetry is how long a transition that is still due after its row was re-read waits
+	// before it is tried again: on a follower, in case the leader has died, and on the leader,
+	// in case the row was only slow to change
+	scheduleRetry = 5 * time.Second
+)
+
+// scheduledTransition is the next timed move for one auction: its start while SCHEDULED and
+// its end while LIVE. A live auction also has one for its next ending-soon notice
+type scheduledTransition struct {
+	auctionID uuid.UUID
+	from      models.AuctionStatus
+	notice    bool // the next ending-soon notice rather than the next status change
+	at        time.Time
+	index     int
+}
+
+func (t *scheduledTransition) key() scheduleKey {
+	return scheduleKey{auctionID: t.auctionID, notice: t.notice}
+}
+
+type scheduleKey struct {
+	auctionID uuid.UUID
+	notice    bool
+}
+
+// EndingNoticeHandler sends whichever ending-soon notice has come due for auction. On success it
+// records the new count on auction, so the scheduler can plan the next one from it
+type EndingNoticeHandler func(ctx context.Context, auction *models.AuctionResponse, now time.Time) (bool, error)
+
+// transitionQueue is a min-heap of scheduled transitions ordered by when they are due
+type transitionQueue []*scheduledTransition
+
+func (q transitionQueue) Len() int           { return len(q) }
+func (q transitionQueue) Less(i, j int) bool { return q[i].at.Before(q[j].at) }
+
+func (q transitionQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *transitionQueue) Push(x any) {
+	entry := x.(*scheduledTransition)
+	entry.index = len(*q)
+	*q = append(*q, entry)
+}
+
+func (q *transitionQueue) Pop() any {
+	old := *q
+	n := len(old)
+	entry := old[n-1]
+	old[n-1] = nil
+	entry.index = -1
+	*q = old[:n-1]
+	return entry
+}
+
+// AuctionScheduler starts and ends auctions at the second they are due, instead of on the
+// worker's next tick. It keeps one timer for the earliest pending transition and follows the
+// state machine's events, so it only has to be told directly when a start or end time is
+// edited. Each move carries the same time guard as the worker's sweep, which stays in place to
+// catch anything the scheduler missed. Ending-soon notices are timed the same way, so short
+// thresholds like a minute land on time. Every instance keeps a schedule, but only the one
+// holding the worker lease fires it
+type AuctionScheduler struct {
+	auctionRepo  models.AuctionInterface
+	stateMachine *AuctionStateMachine
+	elector      *LeaderElector
+	logger       *slog.Logger
+
+	endingNotices []time.Duration // time before the end, longest first
+	noticeHandler EndingNoticeHandler
+
+	mu       sync.Mutex
+	queue    transitionQueue
+	entries  map[scheduleKey]*scheduledTransition
+	wake     chan struct{}
+	stopChan chan struct{}
+}
+
+func NewAuctionScheduler(auctionRepo models.AuctionInterface, stateMachine *AuctionStateMachine, elector *LeaderElector, logger *slog.Logger) *AuctionScheduler {
+	s := &AuctionScheduler{
+		auctionRepo:  auctionRepo,
+		stateMachine: stateMachine,
+		elector:      elector,
+		logger:       logger,
+		entries:      make(map[scheduleKey]*scheduledTransition),
+		wake:         make(chan struct{}, 1),
+		stopChan:     make(chan struct{}),
+	}
+	stateMachine.Subscribe(s.handleAuctionEvent)
+	return s
+}
+
+// HandleEndingNotices has the scheduler time ending-soon notices at notices (longest first)
+// before each live auction's end and send them with handler. It must be called before Start
+func (s *AuctionScheduler) HandleEndingNotices(notices []time.Duration, handler EndingNoticeHandler) {
+	s.endingNotices = notices
+	s.noticeHandler = handler
+}
+
+// Start loads the upcoming starts and ends from the database and begins firing them
+func (s *AuctionScheduler) Start(ctx context.Context) error {
+	err := s.rebuild(ctx)
+	go s.run()
+	return err
+}
+
+// Stop halts the scheduler. Pending transitions are left to the worker's sweep
+func (s *AuctionScheduler) Stop() {
+	close(s.stopChan)
+}
+
+func (s *AuctionScheduler) handleAuctionEvent(ctx context.Context, event AuctionEvent) {
+	s.Schedule(event.Auction)
+}
+
+// Schedule sets the auction's next timed transition and ending-soon notice from its current
+// status, times and notice count. Call it whenever a start or end time changes outside the
+// state machine
+func (s *AuctionScheduler) Schedule(auction *models.Auction) {
+	s.plan(auction, time.Now(), false)
+}
+
+// plan queues the auction's next transition and notice. With retry set, anything already due
+// waits scheduleRetry instead of firing straight away
+func (s *AuctionScheduler) plan(auction *models.Auction, now time.Time, retry bool) {
+	at, ok := transitionTime(auction)
+	if !ok {
+		s.Unschedule(auction.ID)
+		return
+	}
+	if retry && !at.After(now) {
+		at = now.Add(scheduleRetry)
+	}
+	s.scheduleAt(scheduleKey{auctionID: auction.ID}, auction.Status, at)
+
+	noticeKey := scheduleKey{auctionID: auction.ID, notice: true}
+	at, ok = s.noticeTime(auction, now)
+	if !ok {
+		s.unschedule(noticeKey)
+		return
+	}
+	if retry && !at.After(now) {
+		at = now.Add(scheduleRetry)
+	}
+	s.scheduleAt(noticeKey, auction.Status, at)
+}
+
+// transitionTime is when the auction's next timed transition is due, if it has one
+func transitionTime(auction *models.Auction) (time.Time, bool) {
+	switch auction.Status {
+	case constants.AuctionScheduled:
+		return auction.StartTime, true
+	case constants.AuctionLive:
+		return auction.EndTime, true
+	default:
+		return time.Time{}, false
+	}
+}
+
+// noticeTime is when the auction's next ending-soon notice is due, if it has one left
+func (s *AuctionScheduler) noticeTime(auction *models.Auction, now time.Time) (time.Time, bool) {
+	if s.noticeHandler == nil || auction.Status != constants.AuctionLive || !now.Before(auction.EndTime) {
+		return time.Time{}, false
+	}
+	if auction.EndingNoticesSent >= len(s.endingNotices) {
+		return time.Time{}, false
+	}
+	return auction.EndTime.Add(-s.endingNotices[auction.EndingNoticesSent]), true
+}
+
+func (s *AuctionScheduler) scheduleAt(key scheduleKey, status models.AuctionStatus, at time.Time) {
+	s.mu.Lock()
+	if entry, ok := s.entries[key]; ok {
+		// A live auction's end only ever moves later, so an earlier time is a stale read. A
+		// notice can move either way when the end does, and is checked against the row anyway
+		if !key.notice && entry.from == status && status == constants.AuctionLive && at.Before(entry.at) {
+			s.mu.Unlock()
+			return
+		}
+		entry.from = status
+		entry.at = at
+		heap.Fix(&s.queue, entry.index)
+	} else {
+		entry := &scheduledTransition{auctionID: key.auctionID, from: status, notice: key.notice, at: at}
+		heap.Push(&s.queue, entry)
+		s.entries[key] = entry
+	}
+	s.mu.Unlock()
+	s.notify()
+}
+
+// Unschedule drops any pending transition and notice for the auction
+func (s *AuctionScheduler) Unschedule(auctionID uuid.UUID) {
+	s.unschedule(scheduleKey{auctionID: auctionID})
+	s.unschedule(scheduleKey{auctionID: auctionID, notice: true})
+}
+
+func (s *AuctionScheduler) unschedule(key scheduleKey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if entry, ok := s.entries[key]; ok {
+		heap.Remove(&s.queue, entry.index)
+		delete(s.entries, key)
+	}
+}
+
+// notify wakes the run loop so it can re-arm its timer for a new earliest transition
+func (s *AuctionScheduler) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (s *AuctionScheduler) run() {
+	rebuild := time.NewTicker(scheduleRebuildInterval)
+	defer rebuild.Stop()
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+
+	for {
+		var due <-chan time.Time
+		if wait, ok := s.untilNext(); ok {
+			timer.Reset(wait)
+			due = timer.C
+		}
+
+		select {
+		case <-due:
+			s.fireDue()
+		case <-s.wake:
+		case <-rebuild.C:
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			if err := s.rebuild(ctx); err != nil {
+				s.logger.Error("Failed to rebuild auction schedule", "error", err)
+			}
+			cancel()
+		case <-s.stopChan:
+			return
+		}
+		timer.Stop()
+	}
+}
+
+// untilNext reports how long until the earliest pending transition is due
+func (s *AuctionScheduler) untilNext() (time.Duration, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.queue) == 0 {
+		return 0, false
+	}
+	return max(time.Until(s.queue[0].at), 0), true
+}
+
+// fireDue applies every transition that has come due. Entries are taken off the queue first so
+// the state machine's events, which reschedule through the same lock, don't deadlock. Only the
+// leader fires; a follower re-reads each due auction instead, so its schedule follows changes
+// made on other instances and it is ready to take over if the leader dies
+func (s *AuctionScheduler) fireDue() {
+	now := time.Now()
+	leader := s.elector.IsLeader()
+
+	s.mu.Lock()
+	var due []*scheduledTransition
+	for len(s.queue) > 0 && !s.queue[0].at.After(now) {
+		entry := heap.Pop(&s.queue).(*scheduledTransition)
+		delete(s.entries, entry.key())
+		due = append(due, entry)
+	}
+	s.mu.Unlock()
+
+	for _, entry := range due {
+		ctx, cancel := context.WithTimeout(context.Background(), scheduleFireTimeout)
+		switch {
+		case leader && entry.notice:
+			s.fireNotice(ctx, entry, now)
+		case leader:
+			s.fire(ctx, entry, now)
+		default:
+			s.reschedule(ctx, entry.auctionID, now)
+		}
+		cancel()
+	}
+}
+
+func (s *AuctionScheduler) fire(ctx context.Context, entry *scheduledTransition, now time.Time) {
+	var req TransitionRequest
+	switch entry.from {
+	case constants.AuctionScheduled:
+		req = timedTransition(entry.auctionID, constants.AuctionScheduled, constants.AuctionLive, models.TriggerStartTime, "start_time", now)
+	case constants.AuctionLive:
+		req = timedTransition(entry.auctionID, constants.AuctionLive, constants.AuctionEnded, models.TriggerEndTime, "end_time", now)
+	default:
+		return
+	}
+
+	// ErrNoData means the auction already moved on or its time was pushed back, possibly on
+	// another instance that only rescheduled it there, so it is re-read and scheduled afresh
+	_, err := s.stateMachine.Transition(ctx, req)
+	if err == constants.ErrNoData {
+		s.reschedule(ctx, entry.auctionID, now)
+		return
+	}
+	if err != nil {
+		s.logger.Error("Failed to apply scheduled transition", "auction_id", entry.auctionID, "to", req.To, "error", err)
+	}
+}
+
+// fireNotice sends the auction's due ending-soon notice from its current row and plans the
+// next one. The handler re-checks the time and claims the count, so a notice planned from a
+// stale end time or already sent by the worker's sweep isn't sent again
+func (s *AuctionScheduler) fireNotice(ctx context.Context, entry *scheduledTransition, now time.Time) {
+	returned, err := s.auctionRepo.GetAuctionById(ctx, entry.auctionID)
+	if err != nil {
+		if err != constants.ErrNotFound {
+			s.logger.Error("Failed to reload auction for ending soon notice", "auction_id", entry.auctionID, "error", err)
+		}
+		return
+	}
+
+	if _, err := s.noticeHandler(ctx, returned, now); err != nil && err != constants.ErrNoData {
+		s.logger.Error("Failed to send ending soon notice", "auction_id", entry.auctionID, "error", err)
+	}
+	s.plan(&returned.Auction, now, true)
+}
+
+// reschedule schedules an auction whose entry has been taken off the queue from its current row.
+// Anything still due is tried again after scheduleRetry rather than straight away, and an
+// auction that has ended or gone is dropped
+func (s *AuctionScheduler) reschedule(ctx context.Context, auctionID uuid.UUID, now time.Time) {
+	returned, err := s.auctionRepo.GetAuctionById(ctx, auctionID)
+	if err != nil {
+		if err != constants.ErrNotFound {
+			s.logger.Error("Failed to reload scheduled auction", "auction_id", auctionID, "error", err)
+		}
+		return
+	}
+	s.plan(&returned.Auction, now, true)
+}
+
+// rebuild loads every auction due to start or end within the horizon, soonest first. Live
+// auctions are loaded far enough ahead to cover the longest ending-soon notice too
+func (s *AuctionScheduler) rebuild(ctx context.Context) error {
+	horizon := time.Now().Add(scheduleHorizon)
+
+	starting, err := s.auctionRepo.GetDueAuctions(ctx, constants.AuctionScheduled, "start_time", horizon, scheduleLoadLimit)
+	if err != nil {
+		return err
+	}
+	endHorizon := horizon
+	if len(s.endingNotices) > 0 {
+		endHorizon = endHorizon.Add(s.endingNotices[0])
+	}
+	ending, err := s.auctionRepo.GetDueAuctions(ctx, constants.AuctionLive, "end_time", endHorizon, scheduleLoadLimit)
+	if err != nil {
+		return err
+	}
+
+	for _, auction := range append(starting, ending...) {
+		s.Schedule(&auction.Auction)
+	}
+	s.logger.Info("Auction schedule loaded", "starting", len(starting), "ending", len(ending))
+	return nil
+}
@@ -3,28 +3,60 @@ package service
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/joshua-takyi/auction/internal/constants"
 	"github.com/joshua-takyi/auction/internal/helpers"
 	"github.com/joshua-takyi/auction/internal/models"
+	"github.com/shopspring/decimal"
 )
 
+// statusBatchSize caps how many auctions a single worker tick starts or ends
+const statusBatchSize = 100
+
 type AuctionService struct {
-	auctionRepo models.AuctionInterface
-	productRepo models.ProductInterface
+	auctionRepo        models.AuctionInterface
+	productRepo        models.ProductInterface
+	bidRepo            models.BidInterface
+	sealedBidRepo      models.SealedBidInterface
+	lotRepo            models.LotInterface
+	stateMachine       *AuctionStateMachine
+	scheduler          *AuctionScheduler
+	incrementService   *IncrementService
+	notifService       *NotificationService
+	buyNowAfterReserve bool
+	endingNotices      []time.Duration // time before the end, longest first
 }
 
-func NewAuctionService(auctionRepo models.AuctionInterface, productRepo models.ProductInterface) *AuctionService {
+func NewAuctionService(auctionRepo models.AuctionInterface, productRepo models.ProductInterface, bidRepo models.BidInterface, sealedBidRepo models.SealedBidInterface, lotRepo models.LotInterface, stateMachine *AuctionStateMachine, scheduler *AuctionScheduler, incrementService *IncrementService, notifService *NotificationService, buyNowAfterReserve bool, endingNotices []time.Duration) *AuctionService {
 	return &AuctionService{
-		auctionRepo: auctionRepo,
-		productRepo: productRepo,
+		auctionRepo:        auctionRepo,
+		productRepo:        productRepo,
+		bidRepo:            bidRepo,
+		sealedBidRepo:      sealedBidRepo,
+		lotRepo:            lotRepo,
+		stateMachine:       stateMachine,
+		scheduler:          scheduler,
+		incrementService:   incrementService,
+		notifService:       notifService,
+		buyNowAfterReserve: buyNowAfterReserve,
+		endingNotices:      endingNotices,
 	}
 }
 
 func (s *AuctionService) CreateAuction(ctx context.Context, auction *models.Auction, accessToken string, productID uuid.UUID) (*models.Auction, error) {
+	// A seller's listing is always an original; relists are only created by RelistService
+	auction.RelistAttempt = 0
+	auction.PreviousAuctionID = nil
+	auction.RelistedAt = nil
+	return s.createAuction(ctx, auction, accessToken, productID)
+}
 
+// createAuction validates and stores a new auction. An empty access token creates it with the
+// service client
+func (s *AuctionService) createAuction(ctx context.Context, auction *models.Auction, accessToken string, productID uuid.UUID) (*models.Auction, error) {
 	auction.ProductID = productID
 
 	now := time.Now()
@@ -46,10 +78,11 @@ func (s *AuctionService) CreateAuction(ctx context.Context, auction *models.Auct
 	auction.CreatedAt = now
 	auction.UpdatedAt = now
 	auction.Status = constants.AuctionScheduled
-
-	if err := models.Validate.Struct(auction); err != nil {
-		fmt.Printf("[AuctionService] Validation failed: %v\n", err)
-		return nil, constants.ErrInvalidInput
+	if auction.Type == "" {
+		auction.Type = models.AuctionEnglish
+	}
+	if auction.Quantity == 0 {
+		auction.Quantity = 1
 	}
 
 	product, err := s.productRepo.GetProductById(ctx, accessToken, productID)
@@ -60,6 +93,20 @@ func (s *AuctionService) CreateAuction(ctx context.Context, auction *models.Auct
 		return nil, constants.ErrProductNotApproved
 	}
 
+	if auction.Type == models.AuctionDutch {
+		// Dutch auctions take no bids; the drop step stands in for the required increment
+		if auction.MinIncrement.IsZero() {
+			auction.MinIncrement = auction.PriceDropStep
+		}
+	} else if err := s.attachIncrementTable(ctx, auction, product.Category); err != nil {
+		return nil, err
+	}
+
+	if err := models.Validate.Struct(auction); err != nil {
+		fmt.Printf("[AuctionService] Validation failed: %v\n", err)
+		return nil, constants.ErrInvalidInput
+	}
+
 	fmt.Printf("[AuctionService] checking if product %s already have an existing and active auction\n", productID)
 
 	// returnedAuction, err := s.auctionRepo.GetAuctionsByProductID(ctx, accessToken, productID, 1, 0)
@@ -93,34 +140,187 @@ func (s *AuctionService) CreateAuction(ctx context.Context, auction *models.Auct
 		return nil, constants.ErrProductHasActiveAuction
 	}
 
+	if err := validateAuctionTerms(auction); err != nil {
+		return nil, err
+	}
+	auction.ExtensionCount = 0
+
+	fmt.Printf("[AuctionService] creating auction for product %s\n", productID)
+	created, err := s.auctionRepo.CreateAuction(ctx, auction, accessToken, productID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create auction: %w", err)
+	}
+	fmt.Printf("[AuctionService] auction created successfully for product %s\n", created.ID)
+	s.stateMachine.Created(ctx, created, product.OwnerID)
+	return created, nil
+}
+
+// attachIncrementTable links the auction to its increment table, if one applies. MinIncrement is
+// kept at the table's smallest increment since the place_bid RPC still enforces it as a floor
+func (s *AuctionService) attachIncrementTable(ctx context.Context, auction *models.Auction, category string) error {
+	table, err := s.incrementService.ResolveTable(ctx, auction.IncrementTableID, category)
+	if err != nil {
+		return err
+	}
+	if table == nil {
+		return nil
+	}
+	auction.IncrementTableID = &table.ID
+	auction.MinIncrement = table.SmallestIncrement()
+	return nil
+}
+
+// validateAuctionTerms checks the seller-controlled prices, times and soft close settings
+func validateAuctionTerms(auction *models.Auction) error {
+	if !auction.Type.IsValid() {
+		return fmt.Errorf("unknown auction type %q: %w", auction.Type, constants.ErrInvalidInput)
+	}
+	if auction.Type.IsSealed() && auction.BuyNow {
+		return fmt.Errorf("sealed-bid auctions can't offer buy now: %w", constants.ErrInvalidInput)
+	}
+	if auction.Type == models.AuctionDutch {
+		if err := validateDutchTerms(auction); err != nil {
+			return err
+		}
+	}
+	if auction.Type == models.AuctionReverse {
+		if auction.BuyNow {
+			return fmt.Errorf("reverse auctions can't offer buy now: %w", constants.ErrInvalidInput)
+		}
+		if auction.ReservePrice != nil && auction.ReservePrice.GreaterThan(auction.StartPrice) {
+			return fmt.Errorf("a reverse auction's reserve can't be above its start price: %w", constants.ErrInvalidInput)
+		}
+	}
+	if auction.Type == models.AuctionMultiUnit {
+		if auction.BuyNow {
+			return fmt.Errorf("multi-unit auctions can't offer buy now: %w", constants.ErrInvalidInput)
+		}
+		if auction.Quantity < 1 {
+			return fmt.Errorf("multi-unit auctions need at least one unit: %w", constants.ErrInvalidInput)
+		}
+	} else if auction.Quantity != 1 {
+		return fmt.Errorf("only multi-unit auctions can sell more than one unit: %w", constants.ErrInvalidInput)
+	}
+
 	// checking if the start time is less than the end time
 	if auction.StartTime.After(auction.EndTime) {
-		return nil, constants.ErrInvalidInput
+		return constants.ErrInvalidInput
 	}
 
 	if auction.MinIncrement.IsZero() {
-		return nil, fmt.Errorf("auction min increment cannot be zero: %w", constants.ErrInvalidInput)
+		return fmt.Errorf("auction min increment cannot be zero: %w", constants.ErrInvalidInput)
 	}
 
-	if auction.ReservePrice.IsZero() {
-		return nil, fmt.Errorf("auction reserve price cannot be zero: %w", constants.ErrInvalidInput)
+	if auction.ReservePrice == nil || auction.ReservePrice.IsZero() {
+		return fmt.Errorf("auction reserve price cannot be zero: %w", constants.ErrInvalidInput)
 	}
 
 	if auction.EstimatedPrice.IsZero() {
-		return nil, fmt.Errorf("auction estimated price cannot be zero: %w", constants.ErrInvalidInput)
+		return fmt.Errorf("auction estimated price cannot be zero: %w", constants.ErrInvalidInput)
 	}
 
 	if auction.StartPrice.IsZero() {
-		return nil, fmt.Errorf("auction start price cannot be zero: %w", constants.ErrInvalidInput)
+		return fmt.Errorf("auction start price cannot be zero: %w", constants.ErrInvalidInput)
 	}
 
-	fmt.Printf("[AuctionService] creating auction for product %s\n", productID)
-	created, err := s.auctionRepo.CreateAuction(ctx, auction, accessToken, productID)
+	if auction.ExtensionWindowSecs < 0 || auction.ExtensionSecs < 0 || (auction.MaxExtensions != nil && *auction.MaxExtensions < 0) {
+		return fmt.Errorf("auction extension settings cannot be negative: %w", constants.ErrInvalidInput)
+	}
+
+	if auction.RelistAttempts < 0 || auction.RelistDelaySecs < 0 {
+		return fmt.Errorf("auction relist settings cannot be negative: %w", constants.ErrInvalidInput)
+	}
+	if auction.RelistPriceDropPct.IsNegative() || auction.RelistPriceDropPct.GreaterThanOrEqual(decimal.NewFromInt(100)) {
+		return fmt.Errorf("relist price drop must be a percentage below 100: %w", constants.ErrInvalidInput)
+	}
+	if auction.Type == models.AuctionReverse && auction.RelistPriceDropPct.IsPositive() {
+		return fmt.Errorf("a price drop would only make a reverse auction harder to fill: %w", constants.ErrInvalidInput)
+	}
+
+	return nil
+}
+
+// UpdateAuction applies a seller's edits according to the auction's state. SCHEDULED auctions
+// can change any price, time or soft close setting; LIVE auctions can only run longer or lower
+// a reserve that no bid has reached yet; anything later is locked
+func (s *AuctionService) UpdateAuction(ctx context.Context, auctionID uuid.UUID, update models.AuctionUpdate, user *models.User, accessToken string) (*models.Auction, error) {
+	if auctionID == uuid.Nil {
+		return nil, constants.ErrInvalidID
+	}
+
+	returnedAuction, err := s.auctionRepo.GetAuctionById(ctx, auctionID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create auction: %w", err)
+		return nil, err
 	}
-	fmt.Printf("[AuctionService] auction created successfully for product %s\n", created.ID)
-	return created, nil
+
+	if !user.IsAdminOrOwner(returnedAuction.Product.OwnerID) {
+		return nil, constants.ErrForbidden
+	}
+
+	auction := returnedAuction.Auction
+	if update.IsEmpty() {
+		return nil, fmt.Errorf("no fields to update: %w", constants.ErrInvalidInput)
+	}
+
+	switch auction.Status {
+	case constants.AuctionScheduled:
+		update.ApplyTo(&auction)
+		if update.IncrementTableID != nil {
+			if err := s.attachIncrementTable(ctx, &auction, returnedAuction.Product.Category); err != nil {
+				return nil, err
+			}
+			update.MinIncrement = &auction.MinIncrement
+		}
+		if err := validateAuctionTerms(&auction); err != nil {
+			return nil, err
+		}
+		if update.StartTime != nil && auction.StartTime.Before(time.Now()) {
+			return nil, fmt.Errorf("auction start time must be in the future: %w", constants.ErrInvalidInput)
+		}
+
+	case constants.AuctionLive:
+		if !update.OnlyEndTimeOrReserve() {
+			return nil, fmt.Errorf("only the end time and reserve price can change while live: %w", constants.ErrAuctionNotEditable)
+		}
+		if update.EndTime != nil && !update.EndTime.After(auction.EndTime) {
+			return nil, fmt.Errorf("end time can only be extended while live: %w", constants.ErrInvalidInput)
+		}
+		if update.ReservePrice != nil && auction.Type == models.AuctionReverse {
+			// The reserve is a ceiling here, so relaxing it means raising it
+			if auction.ReservePrice != nil && !update.ReservePrice.GreaterThan(*auction.ReservePrice) {
+				return nil, fmt.Errorf("reserve price can only be raised while live: %w", constants.ErrInvalidInput)
+			}
+			if !auction.CurrentBid.IsZero() && !update.ReservePrice.LessThan(auction.CurrentBid) {
+				return nil, fmt.Errorf("reserve price must stay below the current bid: %w", constants.ErrInvalidInput)
+			}
+		} else if update.ReservePrice != nil {
+			if auction.ReservePrice != nil && !update.ReservePrice.LessThan(*auction.ReservePrice) {
+				return nil, fmt.Errorf("reserve price can only be lowered while live: %w", constants.ErrInvalidInput)
+			}
+			if !update.ReservePrice.GreaterThan(auction.CurrentBid) {
+				return nil, fmt.Errorf("reserve price must stay above the current bid: %w", constants.ErrInvalidInput)
+			}
+		}
+
+	default:
+		return nil, constants.ErrAuctionNotEditable
+	}
+
+	fields := update.ToMap()
+	fields["updated_at"] = time.Now()
+	if auction.Status == constants.AuctionLive && update.EndTime != nil {
+		// Notices are only sent while live, so only a live auction has a count to reset
+		fields["ending_notices_sent"] = models.EndingNoticesPassed(*update.EndTime, time.Now(), s.endingNotices)
+	}
+
+	updated, err := s.auctionRepo.UpdateAuction(ctx, fields, accessToken, auctionID)
+	if err != nil {
+		return nil, err
+	}
+	if update.StartTime != nil || update.EndTime != nil {
+		s.scheduler.Schedule(updated)
+	}
+	return updated, nil
 }
 
 func (s *AuctionService) DeleteAuction(ctx context.Context, accessToken string, auctionID uuid.UUID) (string, error) {
@@ -136,7 +336,207 @@ func (s *AuctionService) DeleteAuction(ctx context.Context, accessToken string,
 		return "", constants.ErrLiveAuction
 	}
 
-	return s.auctionRepo.DeleteAuction(ctx, accessToken, auctionID)
+	msg, err := s.auctionRepo.DeleteAuction(ctx, accessToken, auctionID)
+	if err != nil {
+		return "", err
+	}
+	s.scheduler.Unschedule(auctionID)
+	return msg, nil
+}
+
+// BuyNow ends a live auction immediately at its Buy It Now price with the buyer as winner
+func (s *AuctionService) BuyNow(ctx context.Context, auctionID, buyerID uuid.UUID) (*models.Auction, error) {
+	if auctionID == uuid.Nil {
+		return nil, constants.ErrInvalidID
+	}
+
+	returnedAuction, err := s.auctionRepo.GetAuctionById(ctx, auctionID)
+	if err != nil {
+		return nil, err
+	}
+	auction := returnedAuction.Auction
+
+	if !auction.BuyNow || auction.BuyNowPrice.IsZero() || auction.Type.IsSealed() || auction.Type == models.AuctionMultiUnit || auction.Type == models.AuctionReverse {
+		return nil, constants.ErrBuyNowUnavailable
+	}
+	if auction.Status != constants.AuctionLive {
+		return nil, constants.ErrAuctionNotLive
+	}
+	if time.Now().After(auction.EndTime) {
+		return nil, constants.ErrAuctionEnded
+	}
+	if returnedAuction.Product.OwnerID == buyerID {
+		return nil, constants.ErrBuyOwnAuction
+	}
+	if auction.CurrentBid.GreaterThanOrEqual(auction.BuyNowPrice) {
+		return nil, constants.ErrBuyNowUnavailable
+	}
+	if !s.buyNowAfterReserve && auction.ReservePrice != nil && auction.CurrentBid.GreaterThanOrEqual(*auction.ReservePrice) {
+		return nil, constants.ErrReserveMet
+	}
+
+	// Conditional on the current bid that was checked, so a bid landing in between wins the race
+	bought, err := s.stateMachine.Transition(ctx, TransitionRequest{
+		AuctionID: auctionID,
+		From:      constants.AuctionLive,
+		To:        constants.AuctionEnded,
+		Trigger:   models.TriggerBuyNow,
+		ActorID:   &buyerID,
+		Guard: models.TransitionGuard{
+			Eq: map[string]string{
+				"buy_now":     "true",
+				"current_bid": auction.CurrentBid.String(),
+			},
+		},
+		Fields: map[string]any{
+			"winner_id":   buyerID,
+			"current_bid": auction.BuyNowPrice,
+		},
+	})
+	if err != nil {
+		if err == constants.ErrNoData {
+			// A bid or status change landed between the checks and the update
+			return nil, constants.ErrBuyNowUnavailable
+		}
+		return nil, err
+	}
+
+	// The sale has gone through at this point, so a failed product update is only logged
+	if err := s.productRepo.UpdateProductStatus(ctx, auction.ProductID, constants.ProductSold); err != nil {
+		fmt.Printf("[AuctionService] failed to mark product %s as sold: %v\n", auction.ProductID, err)
+	}
+
+	s.notifService.NotifyAuctionBoughtNow(bought.RoomID.String(), buyerID.String(), bought.BuyNowPrice.String())
+	s.notifService.NotifyAuctionWon(buyerID.String(), returnedAuction.Product.Title)
+
+	// The buyer is never the seller, so they don't get to see the reserve
+	bought.PresentTo(nil, returnedAuction.Product.OwnerID)
+	return bought, nil
+}
+
+// CancelAuction calls off an auction that hasn't finished. Sellers can cancel while it is still
+// SCHEDULED; once it is LIVE only an admin can (e.g. for fraud). The auction is kept as CANCELLED
+// along with its bids, and every bidder is told why
+func (s *AuctionService) CancelAuction(ctx context.Context, auctionID uuid.UUID, reason string, user *models.User) (*models.Auction, error) {
+	if auctionID == uuid.Nil {
+		return nil, constants.ErrInvalidID
+	}
+
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, constants.ErrCancelReasonRequired
+	}
+
+	returnedAuction, err := s.auctionRepo.GetAuctionById(ctx, auctionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.IsAdminOrOwner(returnedAuction.Product.OwnerID) {
+		return nil, constants.ErrForbidden
+	}
+
+	status := returnedAuction.Status
+	switch status {
+	case constants.AuctionScheduled:
+	case constants.AuctionLive:
+		if !user.IsAdmin() {
+			return nil, fmt.Errorf("only an admin can cancel a live auction: %w", constants.ErrForbidden)
+		}
+	default:
+		return nil, constants.ErrAuctionNotCancellable
+	}
+
+	trigger := models.TriggerSellerCancel
+	if !user.IsOwner(returnedAuction.Product.OwnerID) || status == constants.AuctionLive {
+		trigger = models.TriggerAdminCancel
+	}
+
+	now := time.Now()
+	cancelled, err := s.stateMachine.Transition(ctx, TransitionRequest{
+		AuctionID: auctionID,
+		From:      status,
+		To:        constants.AuctionCancelled,
+		Trigger:   trigger,
+		ActorID:   &user.ID,
+		Reason:    reason,
+		Fields: map[string]any{
+			"cancelled_at":  now,
+			"cancelled_by":  user.ID,
+			"cancel_reason": reason,
+		},
+	})
+	if err != nil {
+		if err == constants.ErrNoData {
+			// The worker moved the auction on between the check and the update
+			return nil, constants.ErrAuctionNotCancellable
+		}
+		return nil, err
+	}
+
+	// The product goes back to the seller so it can be auctioned again
+	if err := s.productRepo.UpdateProductStatus(ctx, cancelled.ProductID, constants.ProductApproved); err != nil {
+		fmt.Printf("[AuctionService] failed to release product %s: %v\n", cancelled.ProductID, err)
+	}
+
+	s.notifService.NotifyAuctionCancelled(cancelled.RoomID.String(), auctionID.String(), reason)
+
+	bids, err := auctionBids(ctx, s.bidRepo, s.sealedBidRepo, s.lotRepo, &returnedAuction.Auction)
+	if err != nil {
+		fmt.Printf("[AuctionService] failed to load bidders of cancelled auction %s: %v\n", auctionID, err)
+		return cancelled, nil
+	}
+	notified := make(map[uuid.UUID]bool)
+	for _, bid := range bids {
+		if notified[bid.BidBy] {
+			continue
+		}
+		notified[bid.BidBy] = true
+		s.notifService.NotifyBidderAuctionCancelled(bid.BidBy.String(), auctionID.String(), returnedAuction.Product.Title, reason)
+	}
+
+	return cancelled, nil
+}
+
+// GetAuctionForViewer loads an auction for display. Cancelled auctions are hidden from the public
+// and only shown to the seller, admins and anyone who bid on them. The reserve is only shown to
+// the seller and admins. viewer is nil for guests
+func (s *AuctionService) GetAuctionForViewer(ctx context.Context, auctionID uuid.UUID, viewer *models.User) (*models.AuctionResponse, error) {
+	auction, err := s.GetAuctionById(ctx, auctionID)
+	if err != nil {
+		return nil, err
+	}
+	if auction.Status == constants.AuctionCancelled {
+		visible, err := s.canSeeCancelled(ctx, auction, viewer)
+		if err != nil {
+			return nil, err
+		}
+		if !visible {
+			return nil, constants.ErrNotFound
+		}
+	}
+	auction.PresentTo(viewer)
+	return auction, nil
+}
+
+func (s *AuctionService) canSeeCancelled(ctx context.Context, auction *models.AuctionResponse, viewer *models.User) (bool, error) {
+	if viewer == nil {
+		return false, nil
+	}
+	if viewer.IsAdminOrOwner(auction.Product.OwnerID) {
+		return true, nil
+	}
+
+	bids, err := auctionBids(ctx, s.bidRepo, s.sealedBidRepo, s.lotRepo, &auction.Auction)
+	if err != nil {
+		return false, err
+	}
+	for _, bid := range bids {
+		if bid.BidBy == viewer.ID {
+			return true, nil
+		}
+	}
+	return false, nil
 }
 
 func (s *AuctionService) GetAuctionById(ctx context.Context, auctionID uuid.UUID) (*models.AuctionResponse, error) {
@@ -144,45 +544,167 @@ func (s *AuctionService) GetAuctionById(ctx context.Context, auctionID uuid.UUID
 	if err != nil {
 		return nil, err
 	}
-	// auction.Auction.ReservePrice = &decimal.Decimal{}
+
+	if auction.Status != constants.AuctionScheduled && auction.Status != constants.AuctionLive {
+		return auction, nil
+	}
+
+	// Sealed bids only have to reach the start price, and anything more would leak the bids.
+	// A Dutch auction's next bid is simply accepting its current price, a lot's is the unit
+	// price that would take a unit from the current winners, and a reverse auction reports the
+	// highest bid it accepts instead
+	next := auction.StartPrice
+	switch {
+	case auction.Type == models.AuctionReverse:
+		table, err := s.incrementService.TableFor(ctx, &auction.Auction)
+		if err != nil {
+			return nil, err
+		}
+		nextMax := auction.NextMaximumBid(auction.IncrementAt(table, auction.CurrentBid))
+		auction.NextMaxBid = &nextMax
+		return auction, nil
+	case auction.Type == models.AuctionDutch:
+		next = auction.DutchPriceAt(time.Now())
+	case auction.Type == models.AuctionMultiUnit:
+		next, err = s.lotNextMinimumBid(ctx, &auction.Auction)
+		if err != nil {
+			return nil, err
+		}
+	case !auction.Type.IsSealed():
+		next, err = s.incrementService.NextMinimumBid(ctx, &auction.Auction)
+		if err != nil {
+			return nil, err
+		}
+	}
+	auction.NextMinBid = &next
 	return auction, nil
 }
 
-func (s *AuctionService) ListAuctions(ctx context.Context, limit, offset int) ([]*models.AuctionResponse, int64, error) {
+// GetLotAllocations lists the winners of a settled multi-unit auction. Bids on a lot are public,
+// so the result is too
+func (s *AuctionService) GetLotAllocations(ctx context.Context, auctionID uuid.UUID) ([]*models.LotAllocation, error) {
+	if auctionID == uuid.Nil {
+		return nil, constants.ErrInvalidID
+	}
+	returnedAuction, err := s.auctionRepo.GetAuctionById(ctx, auctionID)
+	if err != nil {
+		return nil, err
+	}
+	if returnedAuction.Type != models.AuctionMultiUnit {
+		return nil, fmt.Errorf("only multi-unit auctions have allocations: %w", constants.ErrInvalidInput)
+	}
+	return s.lotRepo.GetLotAllocations(ctx, auctionID)
+}
+
+func (s *AuctionService) lotNextMinimumBid(ctx context.Context, auction *models.Auction) (decimal.Decimal, error) {
+	table, err := s.incrementService.TableFor(ctx, auction)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	bids, err := s.lotRepo.GetLotBids(ctx, auction.ID)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	return lotNextMinimumBid(auction, table, bids), nil
+}
+
+func (s *AuctionService) ListAuctions(ctx context.Context, limit, offset int, viewer *models.User) ([]*models.AuctionResponse, int64, error) {
 	if limit <= 0 {
 		limit = 10
 	}
 	if offset < 0 {
 		offset = 0
 	}
-	return s.auctionRepo.ListAuctions(ctx, limit, offset)
+	auctions, total, err := s.auctionRepo.ListAuctions(ctx, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	presentAuctions(auctions, viewer)
+	return auctions, total, nil
 }
 
-func (s *AuctionService) SearchAuctions(ctx context.Context, query string, limit, offset int) ([]*models.AuctionResponse, int64, error) {
+func (s *AuctionService) SearchAuctions(ctx context.Context, query string, limit, offset int, viewer *models.User) ([]*models.AuctionResponse, int64, error) {
 	if limit <= 0 {
 		limit = 10
 	}
 	if offset < 0 {
 		offset = 0
 	}
-	return s.auctionRepo.SearchAuctions(ctx, query, limit, offset)
+	auctions, total, err := s.auctionRepo.SearchAuctions(ctx, query, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	presentAuctions(auctions, viewer)
+	return auctions, total, nil
 }
 
-func (s *AuctionService) FilterAuctions(ctx context.Context, filter models.AuctionFilter, limit, offset int) ([]*models.AuctionResponse, int64, error) {
+func (s *AuctionService) FilterAuctions(ctx context.Context, filter models.AuctionFilter, limit, offset int, viewer *models.User) ([]*models.AuctionResponse, int64, error) {
 	if limit <= 0 {
 		limit = 10
 	}
 	if offset < 0 {
 		offset = 0
 	}
-	return s.auctionRepo.FilterAuctions(ctx, filter, limit, offset)
+	auctions, total, err := s.auctionRepo.FilterAuctions(ctx, filter, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	presentAuctions(auctions, viewer)
+	return auctions, total, nil
 }
 
-func (s *AuctionService) UpdateAuctionStatuses(ctx context.Context) (map[string]any, error) {
-	return s.auctionRepo.UpdateAuctionStatuses(ctx)
+// AdvanceAuctions starts SCHEDULED auctions whose start time has passed and ends LIVE auctions
+// whose end time has passed. Each move is guarded by the time it was due at, so an auction whose
+// end time was pushed back by a late bid in the meantime stays live
+func (s *AuctionService) AdvanceAuctions(ctx context.Context) (started int, ended int, err error) {
+	now := time.Now()
+
+	due, err := s.auctionRepo.GetDueAuctions(ctx, constants.AuctionScheduled, "start_time", now, statusBatchSize)
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, auction := range due {
+		if s.advance(ctx, auction.ID, constants.AuctionScheduled, constants.AuctionLive, models.TriggerStartTime, "start_time", now) {
+			started++
+		}
+	}
+
+	due, err = s.auctionRepo.GetDueAuctions(ctx, constants.AuctionLive, "end_time", now, statusBatchSize)
+	if err != nil {
+		return started, 0, err
+	}
+	for _, auction := range due {
+		if s.advance(ctx, auction.ID, constants.AuctionLive, constants.AuctionEnded, models.TriggerEndTime, "end_time", now) {
+			ended++
+		}
+	}
+	return started, ended, nil
 }
 
-func (s *AuctionService) Recommendation(ctx context.Context, category string, currentID string, limit, offset int) ([]*models.AuctionResponse, int64, error) {
+func (s *AuctionService) advance(ctx context.Context, auctionID uuid.UUID, from, to models.AuctionStatus, trigger models.TransitionTrigger, timeColumn string, now time.Time) bool {
+	_, err := s.stateMachine.Transition(ctx, timedTransition(auctionID, from, to, trigger, timeColumn, now))
+	if err != nil {
+		if err != constants.ErrNoData {
+			fmt.Printf("[AuctionService] failed to move auction %s to %s: %v\n", auctionID, to, err)
+		}
+		return false
+	}
+	return true
+}
+
+// timedTransition is a move that is due once timeColumn has passed. The guard keeps it from
+// applying if the time was pushed back after the auction was picked up
+func timedTransition(auctionID uuid.UUID, from, to models.AuctionStatus, trigger models.TransitionTrigger, timeColumn string, now time.Time) TransitionRequest {
+	return TransitionRequest{
+		AuctionID: auctionID,
+		From:      from,
+		To:        to,
+		Trigger:   trigger,
+		Guard:     models.TransitionGuard{Before: map[string]time.Time{timeColumn: now}},
+	}
+}
+
+func (s *AuctionService) Recommendation(ctx context.Context, category string, currentID string, limit, offset int, viewer *models.User) ([]*models.AuctionResponse, int64, error) {
 	if limit <= 0 {
 		limit = 10
 	}
@@ -190,7 +712,19 @@ func (s *AuctionService) Recommendation(ctx context.Context, category string, cu
 		offset = 0
 	}
 
-	return s.auctionRepo.Recommendation(ctx, category, currentID, limit, offset)
+	auctions, total, err := s.auctionRepo.Recommendation(ctx, category, currentID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	presentAuctions(auctions, viewer)
+	return auctions, total, nil
+}
+
+// presentAuctions prepares every auction in a listing for viewer
+func presentAuctions(auctions []*models.AuctionResponse, viewer *models.User) {
+	for _, auction := range auctions {
+		auction.PresentTo(viewer)
+	}
 }
 
 func (s *AuctionService) GetAuctionSummary(ctx context.Context, userID uuid.UUID, limit, offset int, accessToken string) ([]models.AuctionResponse, error) {
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/joshua-takyi/auction/internal/constants"
+	"github.com/joshua-takyi/auction/internal/models"
+)
+
+// AuctionEvent is published after an auction has changed status
+type AuctionEvent struct {
+	Auction    *models.Auction
+	Transition models.AuctionTransition
+}
+
+// AuctionEventHandler reacts to an auction changing status. Handlers run synchronously on the
+// goroutine that made the change, so anything slow should be handed off
+type AuctionEventHandler func(ctx context.Context, event AuctionEvent)
+
+// TransitionRequest describes a status change. Guard and Fields are passed through to the
+// conditional update, so the change and its side data are written together
+type TransitionRequest struct {
+	AuctionID uuid.UUID
+	From      models.AuctionStatus
+	To        models.AuctionStatus
+	Trigger   models.TransitionTrigger
+	ActorID   *uuid.UUID
+	Reason    string
+	Guard     models.TransitionGuard
+	Fields    map[string]any
+}
+
+// AuctionStateMachine is the only way an auction's status changes. It checks the move against
+// the lifecycle, applies it as a conditional update, records it in the auction's history and
+// publishes an AuctionEvent
+type AuctionStateMachine struct {
+	auctionRepo models.AuctionInterface
+	logger      *slog.Logger
+
+	mu       sync.RWMutex
+	handlers []AuctionEventHandler
+}
+
+func NewAuctionStateMachine(auctionRepo models.AuctionInterface, logger *slog.Logger) *AuctionStateMachine {
+	return &AuctionStateMachine{
+		auctionRepo: auctionRepo,
+		logger:      logger,
+	}
+}
+
+// Subscribe registers a handler that is called after every transition
+func (m *AuctionStateMachine) Subscribe(handler AuctionEventHandler) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.handlers = append(m.handlers, handler)
+}
+
+// Transition moves an auction from req.From to req.To. It returns ErrInvalidTransition for a
+// move the lifecycle doesn't allow and ErrNoData when the auction has already moved on or the
+// guard no longer holds
+func (m *AuctionStateMachine) Transition(ctx context.Context, req TransitionRequest) (*models.Auction, error) {
+	if !req.From.CanTransitionTo(req.To) {
+		return nil, fmt.Errorf("%s to %s: %w", req.From, req.To, constants.ErrInvalidTransition)
+	}
+
+	auction, err := m.auctionRepo.TransitionAuction(ctx, req.AuctionID, req.From, req.To, req.Guard, req.Fields)
+	if err != nil {
+		return nil, err
+	}
+
+	m.commit(ctx, auction, models.AuctionTransition{
+		ID:         uuid.New(),
+		AuctionID:  req.AuctionID,
+		FromStatus: req.From,
+		ToStatus:   req.To,
+		Trigger:    req.Trigger,
+		ActorID:    req.ActorID,
+		Reason:     req.Reason,
+		CreatedAt:  time.Now(),
+	})
+	return auction, nil
+}
+
+// Created records the initial SCHEDULED state of a newly created auction
+func (m *AuctionStateMachine) Created(ctx context.Context, auction *models.Auction, actorID uuid.UUID) {
+	m.commit(ctx, auction, models.AuctionTransition{
+		ID:        uuid.New(),
+		AuctionID: auction.ID,
+		ToStatus:  auction.Status,
+		Trigger:   models.TriggerCreated,
+		ActorID:   &actorID,
+		CreatedAt: time.Now(),
+	})
+}
+
+// commit records and publishes a transition that has already been applied. A failure to
+// record is only logged since the status change itself can't be undone at this point
+func (m *AuctionStateMachine) commit(ctx context.Context, auction *models.Auction, transition models.AuctionTransition) {
+	if err := m.auctionRepo.RecordAuctionTransition(ctx, &transition); err != nil {
+		m.logger.Error("Failed to record auction transition", "auction_id", transition.AuctionID, "to", transition.ToStatus, "error", err)
+	}
+
+	m.logger.Info("Auction status changed",
+		"auction_id", transition.AuctionID,
+		"from", transition.FromStatus,
+		"to", transition.ToStatus,
+		"trigger", transition.Trigger,
+	)
+
+	m.mu.RLock()
+	handlers := m.handlers
+	m.mu.RUnlock()
+
+	event := AuctionEvent{Auction: auction, Transition: transition}
+	for _, handler := range handlers {
+		handler(ctx, event)
+	}
+}
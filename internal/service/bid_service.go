@@ -3,6 +3,9 @@ package service
 import (
 	"context"
 	"fmt"
+	"sort"
+	"sync"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/joshua-takyi/auction/internal/constants"
@@ -12,22 +15,39 @@ import (
 )
 
 type BidService struct {
-	bidRepo      models.BidInterface
-	auctionRepo  models.AuctionInterface
-	jwtManager   *jwt.JWTManager
-	notifService *NotificationService
+	bidRepo          models.BidInterface
+	sealedBidRepo    models.SealedBidInterface
+	lotRepo          models.LotInterface
+	maxBidRepo       models.MaxBidInterface
+	auctionRepo      models.AuctionInterface
+	scheduler        *AuctionScheduler
+	incrementService *IncrementService
+	jwtManager       *jwt.JWTManager
+	notifService     *NotificationService
+	endingNotices    []time.Duration // time before the end, longest first
+	proxyLocks       sync.Map        // auction id -> *sync.Mutex
 }
 
-func NewBidService(bidRepo models.BidInterface, auctionRepo models.AuctionInterface, jwtManager *jwt.JWTManager, notifService *NotificationService) *BidService {
+func NewBidService(bidRepo models.BidInterface, sealedBidRepo models.SealedBidInterface, lotRepo models.LotInterface, maxBidRepo models.MaxBidInterface, auctionRepo models.AuctionInterface, scheduler *AuctionScheduler, incrementService *IncrementService, jwtManager *jwt.JWTManager, notifService *NotificationService, endingNotices []time.Duration) *BidService {
 	return &BidService{
-		bidRepo:      bidRepo,
-		auctionRepo:  auctionRepo,
-		jwtManager:   jwtManager,
-		notifService: notifService,
+		bidRepo:          bidRepo,
+		sealedBidRepo:    sealedBidRepo,
+		lotRepo:          lotRepo,
+		maxBidRepo:       maxBidRepo,
+		auctionRepo:      auctionRepo,
+		scheduler:        scheduler,
+		incrementService: incrementService,
+		jwtManager:       jwtManager,
+		notifService:     notifService,
+		endingNotices:    endingNotices,
 	}
 }
 
-func (s *BidService) PlaceBid(ctx context.Context, amount decimal.Decimal, auctionID uuid.UUID, accessToken string) (map[string]any, error) {
+// PlaceBid places a bid of amount for the caller. When maxAmount is set it is stored as the
+// caller's secret proxy maximum, and competing proxies are resolved before returning.
+// quantity only applies to multi-unit auctions, where amount is the unit price; elsewhere it
+// must be 0 or 1
+func (s *BidService) PlaceBid(ctx context.Context, amount decimal.Decimal, maxAmount *decimal.Decimal, quantity int, auctionID uuid.UUID, accessToken string) (map[string]any, error) {
 	// 1. Verify user from token
 	userAuth, err := s.jwtManager.VerifySupabaseToken(accessToken)
 	if err != nil {
@@ -39,6 +59,57 @@ func (s *BidService) PlaceBid(ctx context.Context, amount decimal.Decimal, aucti
 		return nil, fmt.Errorf("invalid user id in token: %w", err)
 	}
 
+	return s.placeBid(ctx, bidderID, amount, maxAmount, quantity, auctionID, accessToken)
+}
+
+// PlaceBidAs places a bid for a bidder whose identity was already established some other way,
+// such as the ticket that opened their websocket. There's no user token to hand on, so the bid
+// is written with the service client
+func (s *BidService) PlaceBidAs(ctx context.Context, bidderID uuid.UUID, amount decimal.Decimal, maxAmount *decimal.Decimal, quantity int, auctionID uuid.UUID) (map[string]any, error) {
+	return s.placeBid(ctx, bidderID, amount, maxAmount, quantity, auctionID, "")
+}
+
+func (s *BidService) placeBid(ctx context.Context, bidderID uuid.UUID, amount decimal.Decimal, maxAmount *decimal.Decimal, quantity int, auctionID uuid.UUID, accessToken string) (map[string]any, error) {
+	if maxAmount != nil && maxAmount.LessThan(amount) {
+		return nil, constants.ErrMaxBidTooLow
+	}
+
+	// Proxy resolution reads and writes several bids, so serialise it per auction
+	lock := s.auctionLock(auctionID)
+	lock.Lock()
+	defer lock.Unlock()
+
+	auction, err := s.auctionRepo.GetAuctionById(ctx, auctionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load auction: %w", err)
+	}
+	if !auction.Type.TakesBids() {
+		return nil, constants.ErrNotBiddable
+	}
+	if auction.Type == models.AuctionMultiUnit {
+		return s.placeLotBid(ctx, auction, bidderID, amount, maxAmount, quantity)
+	}
+	if quantity > 1 {
+		return nil, constants.ErrInvalidBidQuantity
+	}
+	if auction.Type == models.AuctionReverse {
+		return s.placeReverseBid(ctx, auction, bidderID, amount, maxAmount)
+	}
+	if auction.Type.IsSealed() {
+		return s.placeSealedBid(ctx, auction, bidderID, amount, maxAmount)
+	}
+
+	table, err := s.incrementService.TableFor(ctx, &auction.Auction)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load increment table: %w", err)
+	}
+
+	// The RPC only knows the flat min_increment, so the schedule is checked here first
+	nextMinimum := auction.NextMinimumBid(auction.IncrementAt(table, auction.CurrentBid))
+	if amount.LessThan(nextMinimum) {
+		return nil, fmt.Errorf("the next bid must be at least %s: %w", nextMinimum, constants.ErrBidTooLow)
+	}
+
 	// 2. Call the RPC for atomic bid placement
 	result, err := s.bidRepo.PlaceBid(ctx, auctionID, bidderID, amount, accessToken)
 	if err != nil {
@@ -51,7 +122,20 @@ func (s *BidService) PlaceBid(ctx context.Context, amount decimal.Decimal, aucti
 		return nil, fmt.Errorf("%s", errMsg)
 	}
 
-	// 3. Trigger Real-time Notifications
+	// 3. Store the proxy maximum only once the opening bid has been accepted
+	if maxAmount != nil {
+		_, err := s.maxBidRepo.UpsertMaxBid(ctx, &models.MaxBid{
+			AuctionID: auctionID,
+			BidderID:  bidderID,
+			MaxAmount: *maxAmount,
+			UpdatedAt: time.Now(),
+		}, accessToken)
+		if err != nil {
+			return nil, fmt.Errorf("failed to save maximum bid: %w", err)
+		}
+	}
+
+	// 4. Trigger Real-time Notifications
 	roomID, _ := result["room_id"].(string)
 	newPriceStr := amount.String()
 
@@ -64,9 +148,394 @@ func (s *BidService) PlaceBid(ctx context.Context, amount decimal.Decimal, aucti
 		s.notifService.NotifyOutbid(prevWinnerID, roomID, newPriceStr)
 	}
 
+	// 5. Let competing proxies respond to the new price
+	leader, price, autoBids, err := s.resolveProxyBids(ctx, &auction.Auction, table, roomID, bidderID, amount)
+	if err != nil {
+		return nil, err
+	}
+
+	s.announceReserveMet(ctx, &auction.Auction, price, roomID)
+
+	// 6. Soft close: late bids push the end time out
+	endTime, extended := s.extendIfLate(ctx, &auction.Auction, roomID)
+
+	result["auto_bids"] = autoBids
+	result["current_bid"] = price.String()
+	result["is_winning"] = leader == bidderID
+	result["end_time"] = endTime
+	result["extended"] = extended
+
 	return result, nil
 }
 
+// placeSealedBid records the caller's bid on a sealed-bid auction, or revises it if they already
+// have one. Nothing is broadcast and the reply only describes the caller's own bid
+func (s *BidService) placeSealedBid(ctx context.Context, auction *models.AuctionResponse, bidderID uuid.UUID, amount decimal.Decimal, maxAmount *decimal.Decimal) (map[string]any, error) {
+	if maxAmount != nil {
+		return nil, constants.ErrSealedBidMaxAmount
+	}
+	if auction.Status != constants.AuctionLive {
+		return nil, constants.ErrAuctionNotLive
+	}
+	if time.Now().After(auction.EndTime) {
+		return nil, constants.ErrAuctionEnded
+	}
+	if auction.Product.OwnerID == bidderID {
+		return nil, constants.ErrBidOnOwnAuction
+	}
+	if amount.LessThan(auction.StartPrice) {
+		return nil, fmt.Errorf("the bid must be at least %s: %w", auction.StartPrice, constants.ErrBidTooLow)
+	}
+
+	revision := 1
+	existing, err := s.sealedBidRepo.GetSealedBid(ctx, auction.ID, bidderID)
+	if err != nil && err != constants.ErrNoData {
+		return nil, err
+	}
+	if existing != nil {
+		revision = existing.Revision + 1
+	}
+
+	bid, err := s.sealedBidRepo.UpsertSealedBid(ctx, &models.SealedBid{
+		AuctionID: auction.ID,
+		BidderID:  bidderID,
+		Amount:    amount,
+		Revision:  revision,
+		UpdatedAt: time.Now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]any{
+		"success":  true,
+		"sealed":   true,
+		"amount":   bid.Amount.String(),
+		"revision": bid.Revision,
+		"end_time": auction.EndTime,
+	}, nil
+}
+
+// placeReverseBid records a bid on a reverse auction, where each bid has to undercut the
+// current best by at least the increment. Proxy maximums only make sense for raising a price,
+// so they aren't offered
+func (s *BidService) placeReverseBid(ctx context.Context, auction *models.AuctionResponse, bidderID uuid.UUID, amount decimal.Decimal, maxAmount *decimal.Decimal) (map[string]any, error) {
+	if maxAmount != nil {
+		return nil, fmt.Errorf("maximum bids are not available on reverse auctions: %w", constants.ErrInvalidInput)
+	}
+	if auction.Status != constants.AuctionLive {
+		return nil, constants.ErrAuctionNotLive
+	}
+	if time.Now().After(auction.EndTime) {
+		return nil, constants.ErrAuctionEnded
+	}
+	if auction.Product.OwnerID == bidderID {
+		return nil, constants.ErrBidOnOwnAuction
+	}
+	if !amount.IsPositive() {
+		return nil, constants.ErrInvalidInput
+	}
+
+	table, err := s.incrementService.TableFor(ctx, &auction.Auction)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load increment table: %w", err)
+	}
+	nextMaximum := auction.NextMaximumBid(auction.IncrementAt(table, auction.CurrentBid))
+	if amount.GreaterThan(nextMaximum) {
+		return nil, fmt.Errorf("the next bid must be at most %s: %w", nextMaximum, constants.ErrBidTooHigh)
+	}
+
+	bids, err := auctionBids(ctx, s.bidRepo, s.sealedBidRepo, s.lotRepo, &auction.Auction)
+	if err != nil {
+		return nil, err
+	}
+
+	bid, err := s.bidRepo.PlaceReverseBid(ctx, auction.ID, bidderID, amount, auction.CurrentBid)
+	if err != nil {
+		if err == constants.ErrNoData {
+			// Another bid moved the price, or the auction closed, since it was loaded
+			return nil, fmt.Errorf("the auction has moved on, please try again: %w", constants.ErrBidTooHigh)
+		}
+		return nil, err
+	}
+
+	roomID := auction.RoomID.String()
+	amountStr := bid.BidAmount.String()
+	s.notifService.NotifyBidPlaced(roomID, bidderID.String(), amountStr)
+	if len(bids) > 0 && bids[0].BidBy != bidderID {
+		s.notifService.NotifyOutbid(bids[0].BidBy.String(), roomID, amountStr)
+	}
+	s.announceReserveMet(ctx, &auction.Auction, bid.BidAmount, roomID)
+
+	endTime, extended := s.extendIfLate(ctx, &auction.Auction, roomID)
+
+	return map[string]any{
+		"success":     true,
+		"room_id":     roomID,
+		"current_bid": amountStr,
+		"is_winning":  true,
+		"end_time":    endTime,
+		"extended":    extended,
+	}, nil
+}
+
+// placeLotBid records the caller's bid on a multi-unit auction, or raises it if they already
+// have one. To enter, a unit price has to beat the lowest price currently winning a unit; a
+// revision can't take back units or lower the price, since other bidders have been priced out
+// by it. Callers hold the auction's lock
+func (s *BidService) placeLotBid(ctx context.Context, auction *models.AuctionResponse, bidderID uuid.UUID, unitPrice decimal.Decimal, maxAmount *decimal.Decimal, quantity int) (map[string]any, error) {
+	if maxAmount != nil {
+		return nil, fmt.Errorf("maximum bids are not available on multi-unit auctions: %w", constants.ErrInvalidInput)
+	}
+	if quantity == 0 {
+		quantity = 1
+	}
+	if quantity < 0 || quantity > auction.Quantity {
+		return nil, constants.ErrInvalidBidQuantity
+	}
+	if auction.Status != constants.AuctionLive {
+		return nil, constants.ErrAuctionNotLive
+	}
+	if time.Now().After(auction.EndTime) {
+		return nil, constants.ErrAuctionEnded
+	}
+	if auction.Product.OwnerID == bidderID {
+		return nil, constants.ErrBidOnOwnAuction
+	}
+
+	bids, err := s.lotRepo.GetLotBids(ctx, auction.ID)
+	if err != nil {
+		return nil, err
+	}
+	var existing *models.LotBid
+	others := make([]*models.LotBid, 0, len(bids))
+	for _, b := range bids {
+		if b.BidderID == bidderID {
+			existing = b
+			continue
+		}
+		others = append(others, b)
+	}
+	if existing != nil && (unitPrice.LessThan(existing.UnitPrice) || quantity < existing.Quantity) {
+		return nil, constants.ErrLotBidReduced
+	}
+
+	table, err := s.incrementService.TableFor(ctx, &auction.Auction)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load increment table: %w", err)
+	}
+	nextMinimum := lotNextMinimumBid(&auction.Auction, table, others)
+	if unitPrice.LessThan(nextMinimum) {
+		return nil, fmt.Errorf("the unit price must be at least %s: %w", nextMinimum, constants.ErrBidTooLow)
+	}
+
+	// The auction lock serialises bids within this instance; the conditional save covers the
+	// bidder's own row against another instance
+	bid, err := s.lotRepo.SaveLotBid(ctx, &models.LotBid{
+		AuctionID: auction.ID,
+		BidderID:  bidderID,
+		Quantity:  quantity,
+		UnitPrice: unitPrice,
+		UpdatedAt: time.Now(),
+	}, existing)
+	if err != nil {
+		if err == constants.ErrNoData {
+			return nil, fmt.Errorf("your lot bid changed while this one was being placed, please try again: %w", constants.ErrLotBidReduced)
+		}
+		return nil, err
+	}
+
+	roomID := auction.RoomID.String()
+	s.notifService.NotifyLotBidPlaced(roomID, bidderID.String(), bid.UnitPrice.String(), bid.Quantity)
+
+	// Tell everyone who held units before this bid and lost some of them
+	if existing != nil {
+		bids = append(others, bid)
+	} else {
+		bids = append(bids, bid)
+	}
+	models.SortLotBids(bids)
+	// Everything below is shown to bidders, so it is priced from the start price; the reserve
+	// only applies at settlement
+	before := lotUnits(auction.Quantity, others, auction.StartPrice)
+	after := lotUnits(auction.Quantity, bids, auction.StartPrice)
+	_, clearing := models.AllocateLot(auction.Quantity, bids, auction.StartPrice)
+	for bidder, units := range before {
+		if after[bidder] < units {
+			s.notifService.NotifyOutbid(bidder.String(), roomID, clearing.String())
+		}
+	}
+	// A lot's reserve is met once any unit would sell, which is when the best unit price reaches it
+	s.announceReserveMet(ctx, &auction.Auction, bids[0].UnitPrice, roomID)
+
+	endTime, extended := s.extendIfLate(ctx, &auction.Auction, roomID)
+
+	return map[string]any{
+		"success":        true,
+		"quantity":       bid.Quantity,
+		"unit_price":     bid.UnitPrice.String(),
+		"units_winning":  after[bidderID],
+		"clearing_price": clearing.String(),
+		"end_time":       endTime,
+		"extended":       extended,
+	}, nil
+}
+
+// lotUnits is how many units each bidder would currently receive
+func lotUnits(quantity int, bids []*models.LotBid, minPrice decimal.Decimal) map[uuid.UUID]int {
+	allocations, _ := models.AllocateLot(quantity, bids, minPrice)
+	units := make(map[uuid.UUID]int, len(allocations))
+	for _, a := range allocations {
+		units[a.BidderID] = a.Quantity
+	}
+	return units
+}
+
+// lotNextMinimumBid is the lowest unit price that wins at least one unit against bids. While
+// the bids don't cover every unit the start price is enough; after that a bid has to beat the
+// clearing price by one increment. The minimum is public, so the reserve is left out of it:
+// bids under the reserve are taken and only lose at settlement
+func lotNextMinimumBid(auction *models.Auction, table *models.IncrementTable, bids []*models.LotBid) decimal.Decimal {
+	allocations, clearing := models.AllocateLot(auction.Quantity, bids, auction.StartPrice)
+	allocated := 0
+	for _, a := range allocations {
+		allocated += a.Quantity
+	}
+	if allocated < auction.Quantity {
+		return auction.StartPrice
+	}
+	return clearing.Add(auction.IncrementAt(table, clearing))
+}
+
+// announceReserveMet broadcasts RESERVE_MET the first time bidding reaches the reserve. The
+// moment is claimed with a conditional update, so concurrent bids only announce it once
+func (s *BidService) announceReserveMet(ctx context.Context, auction *models.Auction, price decimal.Decimal, roomID string) {
+	if auction.ReserveMetAt != nil || auction.ReservePrice == nil || !auction.MeetsReserve(price) {
+		return
+	}
+	if err := s.auctionRepo.MarkReserveMet(ctx, auction.ID, time.Now()); err != nil {
+		if err != constants.ErrNoData {
+			fmt.Printf("[BidService] failed to mark reserve met on auction %s: %v\n", auction.ID, err)
+		}
+		return
+	}
+	s.notifService.NotifyReserveMet(roomID, auction.ID.String())
+}
+
+// extendIfLate applies the auction's soft close rule and broadcasts the new end time.
+// A failed extension is logged rather than failing a bid that has already been accepted
+func (s *BidService) extendIfLate(ctx context.Context, auction *models.Auction, roomID string) (time.Time, bool) {
+	if !auction.ShouldExtend(time.Now()) {
+		return auction.EndTime, false
+	}
+
+	endTime := auction.ExtendedEndTime()
+	noticesSent := models.EndingNoticesPassed(endTime, time.Now(), s.endingNotices)
+	extended, err := s.auctionRepo.ExtendAuction(ctx, auction.ID, endTime, auction.ExtensionCount, noticesSent)
+	if err != nil {
+		fmt.Printf("[BidService] failed to extend auction %s: %v\n", auction.ID, err)
+		return auction.EndTime, false
+	}
+
+	s.scheduler.Schedule(extended)
+	s.notifService.NotifyAuctionExtended(roomID, extended.EndTime, extended.ExtensionCount)
+	return extended.EndTime, true
+}
+
+// resolveProxyBids raises proxy bidders against the current leader until no proxy can
+// outbid it. Every iteration places one real bid and exhausts one competing maximum,
+// so the loop ends after at most one round per proxy. Equal maximums go to the bidder
+// who set theirs first.
+func (s *BidService) resolveProxyBids(ctx context.Context, auction *models.Auction, table *models.IncrementTable, roomID string, leader uuid.UUID, price decimal.Decimal) (uuid.UUID, decimal.Decimal, int, error) {
+	auctionID := auction.ID
+	maxBids, err := s.maxBidRepo.GetMaxBids(ctx, auctionID)
+	if err != nil {
+		return leader, price, 0, err
+	}
+	incrementAt := func(p decimal.Decimal) decimal.Decimal {
+		return auction.IncrementAt(table, p)
+	}
+
+	autoBids := 0
+	for range maxBids {
+		leaderMax := findMaxBid(maxBids, leader)
+		challenger := strongestChallenger(maxBids, leader, price.Add(incrementAt(price)))
+		if challenger == nil {
+			break
+		}
+
+		var bidder uuid.UUID
+		var bidAmount decimal.Decimal
+		if leaderMax != nil && leaderMax.MaxAmount.GreaterThanOrEqual(challenger.MaxAmount) &&
+			(leaderMax.MaxAmount.GreaterThan(challenger.MaxAmount) || !leaderMax.UpdatedAt.After(challenger.UpdatedAt)) {
+			// The leader's proxy defends and the challenger's maximum is exhausted
+			bidder = leader
+			bidAmount = decimal.Min(leaderMax.MaxAmount, challenger.MaxAmount.Add(incrementAt(challenger.MaxAmount)))
+		} else {
+			// The challenger takes the lead for as little as it needs
+			ceiling := price
+			if leaderMax != nil {
+				ceiling = decimal.Max(price, leaderMax.MaxAmount)
+			}
+			bidder = challenger.BidderID
+			bidAmount = decimal.Min(challenger.MaxAmount, ceiling.Add(incrementAt(ceiling)))
+		}
+
+		result, err := s.bidRepo.PlaceBid(ctx, auctionID, bidder, bidAmount, "")
+		if err != nil {
+			return leader, price, autoBids, fmt.Errorf("failed to place proxy bid: %w", err)
+		}
+		if success, _ := result["success"].(bool); !success {
+			// Another request moved the auction on; stop and leave the state as it is
+			fmt.Printf("[BidService] proxy bid for %s rejected: %v\n", bidder, result["error"])
+			break
+		}
+		autoBids++
+
+		amountStr := bidAmount.String()
+		s.notifService.NotifyBidPlaced(roomID, bidder.String(), amountStr)
+		if bidder == leader {
+			s.notifService.NotifyOutbid(challenger.BidderID.String(), roomID, amountStr)
+		} else {
+			s.notifService.NotifyOutbid(leader.String(), roomID, amountStr)
+		}
+
+		leader = bidder
+		price = bidAmount
+	}
+
+	return leader, price, autoBids, nil
+}
+
+func (s *BidService) auctionLock(auctionID uuid.UUID) *sync.Mutex {
+	lock, _ := s.proxyLocks.LoadOrStore(auctionID, &sync.Mutex{})
+	return lock.(*sync.Mutex)
+}
+
+func findMaxBid(maxBids []*models.MaxBid, bidderID uuid.UUID) *models.MaxBid {
+	for _, m := range maxBids {
+		if m.BidderID == bidderID {
+			return m
+		}
+	}
+	return nil
+}
+
+// strongestChallenger picks the highest maximum (earliest on ties) that is not held by
+// the leader and can still beat the minimum next bid. maxBids is already sorted that way.
+func strongestChallenger(maxBids []*models.MaxBid, leader uuid.UUID, minNext decimal.Decimal) *models.MaxBid {
+	for _, m := range maxBids {
+		if m.BidderID == leader {
+			continue
+		}
+		if m.MaxAmount.GreaterThanOrEqual(minNext) {
+			return m
+		}
+		return nil
+	}
+	return nil
+}
+
 func (s *BidService) GetBids(ctx context.Context, auctionID uuid.UUID, accessToken string, limit, offset int) ([]*models.Bid, int64, error) {
 	if limit == 0 {
 		limit = 10
@@ -76,9 +545,103 @@ func (s *BidService) GetBids(ctx context.Context, auctionID uuid.UUID, accessTok
 		offset = 0
 	}
 
+	auction, err := s.auctionRepo.GetAuctionById(ctx, auctionID)
+	if err != nil {
+		return nil, 0, err
+	}
+	if auction.Type.IsSealed() {
+		return s.getSealedBids(ctx, &auction.Auction, accessToken, limit, offset)
+	}
+	if auction.Type == models.AuctionMultiUnit {
+		bids, err := auctionBids(ctx, s.bidRepo, s.sealedBidRepo, s.lotRepo, &auction.Auction)
+		if err != nil {
+			return nil, 0, err
+		}
+		return pageBids(bids, limit, offset)
+	}
+
 	return s.bidRepo.GetBids(ctx, auctionID, accessToken, limit, offset)
 }
 
+// getSealedBids reveals every sealed bid once the auction has closed. Until then callers only
+// ever see their own bid
+func (s *BidService) getSealedBids(ctx context.Context, auction *models.Auction, accessToken string, limit, offset int) ([]*models.Bid, int64, error) {
+	if auction.Status != constants.AuctionEnded && auction.Status != constants.AuctionSettled {
+		userAuth, err := s.jwtManager.VerifySupabaseToken(accessToken)
+		if err != nil {
+			return nil, 0, constants.ErrUnauthorized
+		}
+		bidderID, err := uuid.Parse(userAuth.ID)
+		if err != nil {
+			return nil, 0, fmt.Errorf("invalid user id in token: %w", err)
+		}
+
+		own, err := s.sealedBidRepo.GetSealedBid(ctx, auction.ID, bidderID)
+		if err != nil {
+			return nil, 0, err
+		}
+		return []*models.Bid{own.ToBid()}, 1, nil
+	}
+
+	bids, err := auctionBids(ctx, s.bidRepo, s.sealedBidRepo, s.lotRepo, auction)
+	if err != nil {
+		return nil, 0, err
+	}
+	return pageBids(bids, limit, offset)
+}
+
+// pageBids slices one page out of bids that were loaded in full
+func pageBids(bids []*models.Bid, limit, offset int) ([]*models.Bid, int64, error) {
+	if offset >= len(bids) {
+		return nil, 0, constants.ErrNoData
+	}
+	end := min(offset+limit, len(bids))
+	return bids[offset:end], int64(len(bids)), nil
+}
+
+// auctionBids returns every bid on an auction, best first (highest, or lowest in a reverse
+// auction) and earliest first among equal amounts. Sealed-bid and multi-unit auctions keep their
+// bids out of the public bid history, so those are read from their own tables instead
+func auctionBids(ctx context.Context, bidRepo models.BidInterface, sealedBidRepo models.SealedBidInterface, lotRepo models.LotInterface, auction *models.Auction) ([]*models.Bid, error) {
+	if auction.Type == models.AuctionMultiUnit {
+		lots, err := lotRepo.GetLotBids(ctx, auction.ID)
+		if err != nil {
+			return nil, err
+		}
+		bids := make([]*models.Bid, len(lots))
+		for i, b := range lots {
+			bids[i] = b.ToBid()
+		}
+		return bids, nil
+	}
+	if auction.Type == models.AuctionReverse {
+		bids, err := bidRepo.GetAllBids(ctx, auction.ID)
+		if err != nil {
+			return nil, err
+		}
+		sort.SliceStable(bids, func(i, j int) bool {
+			if !bids[i].BidAmount.Equal(bids[j].BidAmount) {
+				return bids[i].BidAmount.LessThan(bids[j].BidAmount)
+			}
+			return bids[i].CreatedAt.Before(bids[j].CreatedAt)
+		})
+		return bids, nil
+	}
+	if !auction.Type.IsSealed() {
+		return bidRepo.GetAllBids(ctx, auction.ID)
+	}
+
+	sealed, err := sealedBidRepo.GetSealedBids(ctx, auction.ID)
+	if err != nil {
+		return nil, err
+	}
+	bids := make([]*models.Bid, len(sealed))
+	for i, b := range sealed {
+		bids[i] = b.ToBid()
+	}
+	return bids, nil
+}
+
 func (s *BidService) GetUserAuctionWithBid(ctx context.Context, userID uuid.UUID, accessToken string) ([]any, error) {
 	return s.bidRepo.GetUserAuctionWithBid(ctx, userID, accessToken)
 }
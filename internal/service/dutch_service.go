@@ -0,0 +1,210 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/joshua-takyi/auction/internal/constants"
+	"github.com/joshua-takyi/auction/internal/models"
+	"github.com/shopspring/decimal"
+)
+
+// dutchLoadLimit caps how many live Dutch auctions are picked up on startup
+const dutchLoadLimit = 500
+
+// DutchService runs Dutch auctions. The asking price is worked out from the clock, so nothing
+// is stored per drop; a clock per live auction only exists to push each new price to its room.
+// Clocks start and stop with the auction's LIVE state through the state machine's events
+type DutchService struct {
+	auctionRepo  models.AuctionInterface
+	productRepo  models.ProductInterface
+	stateMachine *AuctionStateMachine
+	notifService *NotificationService
+	logger       *slog.Logger
+
+	mu     sync.Mutex
+	clocks map[uuid.UUID]chan struct{} // auction id -> stop channel
+}
+
+func NewDutchService(auctionRepo models.AuctionInterface, productRepo models.ProductInterface, stateMachine *AuctionStateMachine, notifService *NotificationService, logger *slog.Logger) *DutchService {
+	s := &DutchService{
+		auctionRepo:  auctionRepo,
+		productRepo:  productRepo,
+		stateMachine: stateMachine,
+		notifService: notifService,
+		logger:       logger,
+		clocks:       make(map[uuid.UUID]chan struct{}),
+	}
+	stateMachine.Subscribe(s.handleAuctionEvent)
+	return s
+}
+
+// Start picks up the Dutch auctions that were already live when the process started
+func (s *DutchService) Start(ctx context.Context) error {
+	auctions, err := s.auctionRepo.GetAuctionsByStatus(ctx, constants.AuctionLive, dutchLoadLimit)
+	if err != nil {
+		return err
+	}
+	for _, auction := range auctions {
+		if auction.Type == models.AuctionDutch {
+			s.startClock(auction.Auction)
+		}
+	}
+	return nil
+}
+
+// Stop halts every running clock
+func (s *DutchService) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, stop := range s.clocks {
+		close(stop)
+		delete(s.clocks, id)
+	}
+}
+
+func (s *DutchService) handleAuctionEvent(ctx context.Context, event AuctionEvent) {
+	if event.Auction.Type != models.AuctionDutch {
+		return
+	}
+	switch {
+	case event.Transition.ToStatus == constants.AuctionLive:
+		s.startClock(*event.Auction)
+	case event.Transition.FromStatus == constants.AuctionLive:
+		s.stopClock(event.Auction.ID)
+	}
+}
+
+func (s *DutchService) startClock(auction models.Auction) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, running := s.clocks[auction.ID]; running {
+		return
+	}
+	stop := make(chan struct{})
+	s.clocks[auction.ID] = stop
+	go s.runClock(auction, stop)
+}
+
+func (s *DutchService) stopClock(auctionID uuid.UUID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if stop, running := s.clocks[auctionID]; running {
+		close(stop)
+		delete(s.clocks, auctionID)
+	}
+}
+
+// runClock broadcasts the current price straight away and then on every drop until the price
+// reaches its floor, the auction ends or the clock is stopped
+func (s *DutchService) runClock(auction models.Auction, stop chan struct{}) {
+	roomID := auction.RoomID.String()
+	for {
+		now := time.Now()
+		next, more := auction.NextPriceDrop(now)
+		if more && next.After(auction.EndTime) {
+			more = false
+		}
+
+		var nextDropAt time.Time
+		if more {
+			nextDropAt = next
+		}
+		s.notifService.NotifyPriceDrop(roomID, auction.DutchPriceAt(now).String(), nextDropAt)
+
+		if !more {
+			s.stopClock(auction.ID)
+			return
+		}
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-timer.C:
+		case <-stop:
+			timer.Stop()
+			return
+		}
+	}
+}
+
+// Accept sells a live Dutch auction to the buyer at the current asking price. The sale is a
+// LIVE to ENDED transition, so when several buyers accept at once exactly one of them wins
+func (s *DutchService) Accept(ctx context.Context, auctionID, buyerID uuid.UUID) (*models.Auction, error) {
+	if auctionID == uuid.Nil {
+		return nil, constants.ErrInvalidID
+	}
+
+	returnedAuction, err := s.auctionRepo.GetAuctionById(ctx, auctionID)
+	if err != nil {
+		return nil, err
+	}
+	auction := returnedAuction.Auction
+
+	if auction.Type != models.AuctionDutch {
+		return nil, constants.ErrNotDutchAuction
+	}
+	if auction.Status != constants.AuctionLive {
+		return nil, constants.ErrAuctionNotLive
+	}
+	now := time.Now()
+	if now.After(auction.EndTime) {
+		return nil, constants.ErrAuctionEnded
+	}
+	if returnedAuction.Product.OwnerID == buyerID {
+		return nil, constants.ErrAcceptOwnAuction
+	}
+
+	price := auction.DutchPriceAt(now)
+	accepted, err := s.stateMachine.Transition(ctx, TransitionRequest{
+		AuctionID: auctionID,
+		From:      constants.AuctionLive,
+		To:        constants.AuctionEnded,
+		Trigger:   models.TriggerDutchAccept,
+		ActorID:   &buyerID,
+		Fields: map[string]any{
+			"winner_id":   buyerID,
+			"current_bid": price,
+		},
+	})
+	if err != nil {
+		if err == constants.ErrNoData {
+			// Someone else accepted first, or the auction ended in between
+			return nil, constants.ErrAuctionEnded
+		}
+		return nil, err
+	}
+
+	// The sale has gone through at this point, so a failed product update is only logged
+	if err := s.productRepo.UpdateProductStatus(ctx, auction.ProductID, constants.ProductSold); err != nil {
+		fmt.Printf("[DutchService] failed to mark product %s as sold: %v\n", auction.ProductID, err)
+	}
+
+	s.notifService.NotifyAuctionBoughtNow(accepted.RoomID.String(), buyerID.String(), price.String())
+	s.notifService.NotifyAuctionWon(buyerID.String(), returnedAuction.Product.Title)
+
+	// A Dutch reserve is the price floor, which the buyer doesn't get to see either
+	accepted.PresentTo(nil, returnedAuction.Product.OwnerID)
+	return accepted, nil
+}
+
+// validateDutchTerms checks the price schedule of a Dutch auction
+func validateDutchTerms(auction *models.Auction) error {
+	if !auction.PriceDropStep.IsPositive() || auction.PriceDropSecs <= 0 {
+		return fmt.Errorf("dutch auctions need a price drop step and interval: %w", constants.ErrInvalidInput)
+	}
+	if auction.BuyNow {
+		return fmt.Errorf("dutch auctions can't offer buy now: %w", constants.ErrInvalidInput)
+	}
+	floor := decimal.Zero
+	if auction.ReservePrice != nil {
+		floor = *auction.ReservePrice
+	}
+	if !auction.StartPrice.GreaterThan(floor) {
+		return fmt.Errorf("a dutch auction's start price must be above its reserve: %w", constants.ErrInvalidInput)
+	}
+	return nil
+}
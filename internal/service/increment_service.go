@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/joshua-takyi/auction/internal/constants"
+	"github.com/joshua-takyi/auction/internal/models"
+	"github.com/shopspring/decimal"
+)
+
+// IncrementService manages bid increment tables and works out the increment an auction applies
+// at a given price. Auctions without a table keep using their flat MinIncrement
+type IncrementService struct {
+	incrementRepo models.IncrementTableInterface
+}
+
+func NewIncrementService(incrementRepo models.IncrementTableInterface) *IncrementService {
+	return &IncrementService{
+		incrementRepo: incrementRepo,
+	}
+}
+
+func (s *IncrementService) CreateIncrementTable(ctx context.Context, table *models.IncrementTable) (*models.IncrementTable, error) {
+	if err := models.Validate.Struct(table); err != nil {
+		return nil, constants.ErrInvalidInput
+	}
+
+	table.Normalize()
+	if err := table.Validate(); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	table.ID = uuid.New()
+	table.CreatedAt = now
+	table.UpdatedAt = now
+	return s.incrementRepo.CreateIncrementTable(ctx, table)
+}
+
+func (s *IncrementService) ListIncrementTables(ctx context.Context) ([]*models.IncrementTable, error) {
+	return s.incrementRepo.ListIncrementTables(ctx)
+}
+
+// ResolveTable picks the table for a new auction: the one the seller chose, otherwise the
+// table for the product's category, otherwise the global table. It returns nil when none exists
+func (s *IncrementService) ResolveTable(ctx context.Context, tableID *uuid.UUID, category string) (*models.IncrementTable, error) {
+	if tableID != nil {
+		table, err := s.incrementRepo.GetIncrementTableById(ctx, *tableID)
+		if err != nil {
+			if errors.Is(err, constants.ErrNotFound) {
+				return nil, fmt.Errorf("increment table %s does not exist: %w", tableID, constants.ErrInvalidInput)
+			}
+			return nil, err
+		}
+		return table, nil
+	}
+
+	table, err := s.incrementRepo.GetIncrementTableForCategory(ctx, category)
+	if err != nil {
+		if errors.Is(err, constants.ErrNoData) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return table, nil
+}
+
+// TableFor loads the table attached to an auction, or nil when it uses a flat increment
+func (s *IncrementService) TableFor(ctx context.Context, auction *models.Auction) (*models.IncrementTable, error) {
+	if auction.IncrementTableID == nil {
+		return nil, nil
+	}
+	return s.incrementRepo.GetIncrementTableById(ctx, *auction.IncrementTableID)
+}
+
+// NextMinimumBid is the lowest amount the auction accepts as its next bid
+func (s *IncrementService) NextMinimumBid(ctx context.Context, auction *models.Auction) (decimal.Decimal, error) {
+	table, err := s.TableFor(ctx, auction)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	return auction.NextMinimumBid(auction.IncrementAt(table, auction.CurrentBid)), nil
+}
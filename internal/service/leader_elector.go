@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/joshua-takyi/auction/internal/models"
+)
+
+// LeaderElector keeps one process in charge of a named job across every running instance. It
+// renews its lease a few times per ttl; if the leader dies its lease runs out and the next
+// instance to ask takes over. A leader that can't reach the lease store steps down once the
+// lease it last renewed would have expired, so two instances never both believe they lead
+type LeaderElector struct {
+	leases models.LeaseInterface
+	name   string
+	holder string
+	ttl    time.Duration
+	logger *slog.Logger
+
+	mu        sync.Mutex
+	expiresAt time.Time // zero while not leading
+
+	stopChan chan struct{}
+	done     chan struct{}
+}
+
+func NewLeaderElector(leases models.LeaseInterface, name string, ttl time.Duration, logger *slog.Logger) *LeaderElector {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "instance"
+	}
+	return &LeaderElector{
+		leases:   leases,
+		name:     name,
+		holder:   host + "-" + uuid.NewString()[:8],
+		ttl:      ttl,
+		logger:   logger,
+		stopChan: make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Start campaigns for the lease straight away and then keeps renewing it
+func (e *LeaderElector) Start() {
+	go func() {
+		defer close(e.done)
+		ticker := time.NewTicker(e.ttl / 3)
+		defer ticker.Stop()
+
+		e.campaign()
+		for {
+			select {
+			case <-ticker.C:
+				e.campaign()
+			case <-e.stopChan:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops campaigning and hands the lease back so another instance can take over without
+// waiting for it to expire
+func (e *LeaderElector) Stop() {
+	close(e.stopChan)
+	<-e.done
+
+	if !e.IsLeader() {
+		return
+	}
+	e.setExpiry(time.Time{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := e.leases.ReleaseLease(ctx, e.name, e.holder); err != nil {
+		e.logger.Error("Failed to release leadership", "lease", e.name, "holder", e.holder, "error", err)
+	}
+}
+
+// IsLeader reports whether this instance currently holds the lease
+func (e *LeaderElector) IsLeader() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return time.Now().Before(e.expiresAt)
+}
+
+func (e *LeaderElector) campaign() {
+	// The lease is counted from before the request, so it never looks longer here than it is
+	// in the store
+	started := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), e.ttl/3)
+	defer cancel()
+
+	wasLeader := e.IsLeader()
+	acquired, err := e.leases.AcquireLease(ctx, e.name, e.holder, e.ttl)
+	if err != nil {
+		e.logger.Error("Failed to renew leadership", "lease", e.name, "holder", e.holder, "error", err)
+		if wasLeader && !e.IsLeader() {
+			e.logger.Warn("Leadership lost", "lease", e.name, "holder", e.holder)
+		}
+		return
+	}
+
+	if !acquired {
+		e.setExpiry(time.Time{})
+		if wasLeader {
+			e.logger.Warn("Leadership lost", "lease", e.name, "holder", e.holder)
+		}
+		return
+	}
+
+	e.setExpiry(started.Add(e.ttl))
+	if !wasLeader {
+		e.logger.Info("Leadership acquired", "lease", e.name, "holder", e.holder)
+	}
+}
+
+func (e *LeaderElector) setExpiry(at time.Time) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.expiresAt = at
+}
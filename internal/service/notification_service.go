@@ -1,6 +1,9 @@
 package service
 
 import (
+	"strconv"
+	"time"
+
 	"github.com/joshua-takyi/auction/internal/websockets"
 )
 
@@ -26,6 +29,20 @@ func (s *NotificationService) NotifyBidPlaced(roomID string, bidderID string, am
 	s.wsManager.BroadcastNotificationToRoom(roomID, notif)
 }
 
+// NotifyLotBidPlaced tells the room about a bid on a multi-unit auction
+func (s *NotificationService) NotifyLotBidPlaced(roomID string, bidderID string, unitPrice string, quantity int) {
+	notif := websockets.NewNotification(
+		websockets.NotifBidPlaced,
+		"A new bid for "+strconv.Itoa(quantity)+" at "+unitPrice+" each has been placed!",
+		map[string]interface{}{
+			"bidderId": bidderID,
+			"amount":   unitPrice,
+			"quantity": quantity,
+		},
+	)
+	s.wsManager.BroadcastNotificationToRoom(roomID, notif)
+}
+
 func (s *NotificationService) NotifyOutbid(userID string, roomID string, newAmount string) {
 	notif := websockets.NewNotification(
 		websockets.NotifBidOutbid,
@@ -50,3 +67,223 @@ func (s *NotificationService) NotifyAuctionWon(userID string, auctionTitle strin
 	notif.Priority = "high"
 	s.wsManager.SendNotificationToUser(userID, notif)
 }
+
+// NotifyLotWon tells a winner of a multi-unit auction how many units they got and what each costs
+func (s *NotificationService) NotifyLotWon(userID string, auctionTitle string, quantity, requested int, unitPrice string) {
+	notif := websockets.NewNotification(
+		websockets.NotifAuctionWon,
+		"Congratulations! You won "+strconv.Itoa(quantity)+" of "+strconv.Itoa(requested)+" units of "+auctionTitle+" at "+unitPrice+" each",
+		map[string]interface{}{
+			"title":     auctionTitle,
+			"quantity":  quantity,
+			"requested": requested,
+			"unitPrice": unitPrice,
+		},
+	)
+	notif.Priority = "high"
+	s.wsManager.SendNotificationToUser(userID, notif)
+}
+
+// NotifyAuctionStarted tells everyone watching the room that bidding has opened
+func (s *NotificationService) NotifyAuctionStarted(roomID string, auctionID string, endTime time.Time) {
+	notif := websockets.NewNotification(
+		websockets.NotifAuctionStarted,
+		"The auction is now live",
+		map[string]interface{}{
+			"auctionId": auctionID,
+			"endTime":   endTime,
+		},
+	)
+	s.wsManager.BroadcastNotificationToRoom(roomID, notif)
+}
+
+// NotifyAuctionEndingSoon tells the room how long is left before the auction closes
+func (s *NotificationService) NotifyAuctionEndingSoon(roomID string, auctionID string, auctionTitle string, endTime time.Time) {
+	s.wsManager.BroadcastNotificationToRoom(roomID, endingSoonNotification(auctionID, auctionTitle, endTime))
+}
+
+// NotifyBidderAuctionEndingSoon reminds a bidder who may not be watching the room that the
+// auction is about to close
+func (s *NotificationService) NotifyBidderAuctionEndingSoon(userID string, auctionID string, auctionTitle string, endTime time.Time) {
+	notif := endingSoonNotification(auctionID, auctionTitle, endTime)
+	notif.Priority = "high"
+	s.wsManager.SendNotificationToUser(userID, notif)
+}
+
+func endingSoonNotification(auctionID string, auctionTitle string, endTime time.Time) websockets.Notification {
+	return websockets.NewNotification(
+		websockets.NotifAuctionEnding,
+		"The auction for "+auctionTitle+" ends in "+time.Until(endTime).Round(time.Second).String(),
+		map[string]interface{}{
+			"auctionId": auctionID,
+			"title":     auctionTitle,
+			"endTime":   endTime,
+		},
+	)
+}
+
+// NotifyAuctionEnded tells the room that bidding has closed
+func (s *NotificationService) NotifyAuctionEnded(roomID string, auctionID string) {
+	notif := websockets.NewNotification(
+		websockets.NotifAuctionEnded,
+		"The auction has ended",
+		map[string]interface{}{
+			"auctionId": auctionID,
+		},
+	)
+	s.wsManager.BroadcastNotificationToRoom(roomID, notif)
+}
+
+// NotifyAuctionExtended tells the room the soft close moved the end time so clients can reset their countdowns
+func (s *NotificationService) NotifyAuctionExtended(roomID string, endTime time.Time, extensionCount int) {
+	notif := websockets.NewNotification(
+		websockets.NotifAuctionExtended,
+		"Auction extended after a late bid",
+		map[string]interface{}{
+			"roomId":         roomID,
+			"endTime":        endTime,
+			"extensionCount": extensionCount,
+		},
+	)
+	s.wsManager.BroadcastNotificationToRoom(roomID, notif)
+}
+
+// NotifyAuctionBoughtNow tells the room the lot was bought outright and bidding is closed
+func (s *NotificationService) NotifyAuctionBoughtNow(roomID string, buyerID string, price string) {
+	notif := websockets.NewNotification(
+		websockets.NotifAuctionBoughtNow,
+		"This lot was bought outright for "+price,
+		map[string]interface{}{
+			"roomId":  roomID,
+			"buyerId": buyerID,
+			"price":   price,
+		},
+	)
+	notif.Priority = "high"
+	s.wsManager.BroadcastNotificationToRoom(roomID, notif)
+}
+
+// NotifyPriceDrop pushes a Dutch auction's new asking price to the room. nextDropAt is zero once
+// the price has reached its floor
+func (s *NotificationService) NotifyPriceDrop(roomID string, price string, nextDropAt time.Time) {
+	data := map[string]interface{}{
+		"price": price,
+	}
+	if !nextDropAt.IsZero() {
+		data["nextDropAt"] = nextDropAt
+	}
+	notif := websockets.NewNotification(
+		websockets.NotifPriceDrop,
+		"The price has dropped to "+price,
+		data,
+	)
+	s.wsManager.BroadcastNotificationToRoom(roomID, notif)
+}
+
+// NotifyReserveMet tells the room that bidding has reached the reserve, so the lot will sell
+func (s *NotificationService) NotifyReserveMet(roomID string, auctionID string) {
+	notif := websockets.NewNotification(
+		websockets.NotifReserveMet,
+		"The reserve has been met",
+		map[string]interface{}{
+			"auctionId": auctionID,
+		},
+	)
+	s.wsManager.BroadcastNotificationToRoom(roomID, notif)
+}
+
+// NotifyAuctionRelisted tells the seller that an unsold auction was put up again under their
+// relist rule
+func (s *NotificationService) NotifyAuctionRelisted(userID string, auctionTitle string, auctionID string, startTime time.Time) {
+	notif := websockets.NewNotification(
+		websockets.NotifAuctionRelisted,
+		auctionTitle+" didn't sell and has been relisted from "+startTime.Format(time.RFC1123),
+		map[string]interface{}{
+			"title":     auctionTitle,
+			"auctionId": auctionID,
+			"startTime": startTime,
+		},
+	)
+	s.wsManager.SendNotificationToUser(userID, notif)
+}
+
+// NotifyAuctionCancelled tells everyone watching the room that the auction was called off
+func (s *NotificationService) NotifyAuctionCancelled(roomID string, auctionID string, reason string) {
+	notif := websockets.NewNotification(
+		websockets.NotifAuctionCancelled,
+		"This auction has been cancelled",
+		map[string]interface{}{
+			"auctionId": auctionID,
+			"reason":    reason,
+		},
+	)
+	notif.Priority = "high"
+	s.wsManager.BroadcastNotificationToRoom(roomID, notif)
+}
+
+// NotifyBidderAuctionCancelled tells a bidder an auction they bid on was cancelled, wherever they are connected
+func (s *NotificationService) NotifyBidderAuctionCancelled(userID string, auctionID string, auctionTitle string, reason string) {
+	notif := websockets.NewNotification(
+		websockets.NotifAuctionCancelled,
+		"The auction for "+auctionTitle+" you bid on has been cancelled",
+		map[string]interface{}{
+			"auctionId": auctionID,
+			"title":     auctionTitle,
+			"reason":    reason,
+		},
+	)
+	notif.Priority = "high"
+	s.wsManager.SendNotificationToUser(userID, notif)
+}
+
+func (s *NotificationService) NotifyAuctionLost(userID string, auctionTitle string) {
+	notif := websockets.NewNotification(
+		websockets.NotifAuctionLost,
+		"The auction for "+auctionTitle+" has ended without you winning",
+		map[string]interface{}{
+			"title": auctionTitle,
+		},
+	)
+	s.wsManager.SendNotificationToUser(userID, notif)
+}
+
+func (s *NotificationService) NotifyPaymentReminder(userID string, auctionID string, auctionTitle string, dueAt time.Time) {
+	notif := websockets.NewNotification(
+		websockets.NotifPaymentReminder,
+		"Please complete payment for "+auctionTitle+" before "+dueAt.Format(time.RFC1123),
+		map[string]interface{}{
+			"auctionId": auctionID,
+			"title":     auctionTitle,
+			"dueAt":     dueAt,
+		},
+	)
+	notif.Priority = "high"
+	s.wsManager.SendNotificationToUser(userID, notif)
+}
+
+func (s *NotificationService) NotifyPaymentExpired(userID string, auctionTitle string) {
+	notif := websockets.NewNotification(
+		websockets.NotifPaymentExpired,
+		"Your win for "+auctionTitle+" was cancelled because payment was not received in time",
+		map[string]interface{}{
+			"title": auctionTitle,
+		},
+	)
+	notif.Priority = "high"
+	s.wsManager.SendNotificationToUser(userID, notif)
+}
+
+func (s *NotificationService) NotifySecondChanceOffer(userID string, auctionID string, auctionTitle string, price string, dueAt time.Time) {
+	notif := websockets.NewNotification(
+		websockets.NotifSecondChance,
+		"Second chance! You can buy "+auctionTitle+" for your bid of "+price,
+		map[string]interface{}{
+			"auctionId": auctionID,
+			"title":     auctionTitle,
+			"price":     price,
+			"dueAt":     dueAt,
+		},
+	)
+	notif.Priority = "urgent"
+	s.wsManager.SendNotificationToUser(userID, notif)
+}
@@ -0,0 +1,179 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/joshua-takyi/auction/internal/constants"
+	"github.com/joshua-takyi/auction/internal/models"
+	"github.com/joshua-takyi/auction/internal/payments"
+	"github.com/shopspring/decimal"
+)
+
+// PaymentService collects payment from winning bidders through Paystack. An auction only
+// moves to SETTLED once Paystack itself confirms the charge
+type PaymentService struct {
+	paymentRepo  models.PaymentInterface
+	auctionRepo  models.AuctionInterface
+	stateMachine *AuctionStateMachine
+	paystack     *payments.PaystackClient
+	currency     string
+	callbackURL  string
+}
+
+func NewPaymentService(paymentRepo models.PaymentInterface, auctionRepo models.AuctionInterface, stateMachine *AuctionStateMachine, paystack *payments.PaystackClient, currency, callbackURL string) *PaymentService {
+	return &PaymentService{
+		paymentRepo:  paymentRepo,
+		auctionRepo:  auctionRepo,
+		stateMachine: stateMachine,
+		paystack:     paystack,
+		currency:     currency,
+		callbackURL:  callbackURL,
+	}
+}
+
+// Checkout starts (or resumes) a Paystack transaction for the winner of an auction
+func (s *PaymentService) Checkout(ctx context.Context, auctionID uuid.UUID, user *models.User) (*models.Payment, error) {
+	if auctionID == uuid.Nil {
+		return nil, constants.ErrInvalidID
+	}
+
+	returnedAuction, err := s.auctionRepo.GetAuctionById(ctx, auctionID)
+	if err != nil {
+		return nil, err
+	}
+	auction := returnedAuction.Auction
+
+	if auction.Status != constants.AuctionEnded || auction.WinnerID == nil {
+		return nil, constants.ErrAuctionNotPayable
+	}
+	if *auction.WinnerID != user.ID {
+		return nil, constants.ErrNotWinner
+	}
+	if auction.PaymentDueAt != nil && time.Now().After(*auction.PaymentDueAt) {
+		return nil, constants.ErrPaymentDeadlinePassed
+	}
+
+	// Reuse an open transaction so refreshing the checkout page doesn't start a new charge
+	existing, err := s.paymentRepo.GetLatestPayment(ctx, auctionID, user.ID)
+	if err != nil && !errors.Is(err, constants.ErrNoData) {
+		return nil, err
+	}
+	if existing != nil && existing.Status == constants.PaymentPending && existing.Amount.Equal(auction.CurrentBid) {
+		return existing, nil
+	}
+
+	reference := uuid.New().String()
+	initialized, err := s.paystack.InitializeTransaction(ctx, payments.InitializeRequest{
+		Email:       user.Email,
+		Amount:      toMinorUnits(auction.CurrentBid),
+		Reference:   reference,
+		Currency:    s.currency,
+		CallbackURL: s.callbackURL,
+		Metadata: map[string]any{
+			"auction_id": auctionID.String(),
+			"user_id":    user.ID.String(),
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize payment: %w", err)
+	}
+
+	now := time.Now()
+	return s.paymentRepo.CreatePayment(ctx, &models.Payment{
+		ID:               uuid.New(),
+		AuctionID:        auctionID,
+		UserID:           user.ID,
+		Reference:        initialized.Reference,
+		Amount:           auction.CurrentBid,
+		Currency:         s.currency,
+		Status:           constants.PaymentPending,
+		AuthorizationURL: initialized.AuthorizationURL,
+		CreatedAt:        now,
+		UpdatedAt:        now,
+	})
+}
+
+// HandleWebhook processes a Paystack webhook. The body is only trusted once its signature
+// checks out, and even then the transaction is re-verified against the Paystack API
+func (s *PaymentService) HandleWebhook(ctx context.Context, body []byte, signature string) error {
+	event, err := s.paystack.ParseWebhook(body, signature)
+	if err != nil {
+		return err
+	}
+
+	if event.Event != payments.EventChargeSuccess {
+		return nil
+	}
+
+	return s.ConfirmPayment(ctx, event.Data.Reference)
+}
+
+// ConfirmPayment verifies a transaction with Paystack and settles the auction once the full
+// amount has been charged. Confirming an already successful payment is a no-op
+func (s *PaymentService) ConfirmPayment(ctx context.Context, reference string) error {
+	payment, err := s.paymentRepo.GetPaymentByReference(ctx, reference)
+	if err != nil {
+		return err
+	}
+
+	if payment.Status != constants.PaymentSuccess {
+		tx, err := s.paystack.VerifyTransaction(ctx, reference)
+		if err != nil {
+			return fmt.Errorf("failed to verify payment: %w", err)
+		}
+
+		switch tx.Status {
+		case payments.StatusSuccess:
+		case payments.StatusFailed, payments.StatusAbandoned:
+			_, err := s.paymentRepo.UpdatePaymentStatus(ctx, reference, constants.PaymentFailed, nil)
+			return err
+		default:
+			// Still in flight; Paystack will call again once it completes
+			return nil
+		}
+
+		if !tx.Matches(toMinorUnits(payment.Amount), payment.Currency) {
+			return constants.ErrPaymentMismatch
+		}
+
+		paidAt := time.Now()
+		if tx.PaidAt != nil {
+			paidAt = *tx.PaidAt
+		}
+		if _, err := s.paymentRepo.UpdatePaymentStatus(ctx, reference, constants.PaymentSuccess, &paidAt); err != nil {
+			return err
+		}
+	}
+
+	// Guarded on the payer still being the winner, so a payment from a lapsed winner can't settle it
+	_, err = s.stateMachine.Transition(ctx, TransitionRequest{
+		AuctionID: payment.AuctionID,
+		From:      constants.AuctionEnded,
+		To:        constants.AuctionSettled,
+		Trigger:   models.TriggerPayment,
+		ActorID:   &payment.UserID,
+		Reason:    reference,
+		Guard:     models.TransitionGuard{Eq: map[string]string{"winner_id": payment.UserID.String()}},
+	})
+	if err != nil {
+		if errors.Is(err, constants.ErrNoData) {
+			// Either settled by an earlier delivery of the same webhook, or the payer's win
+			// lapsed before the charge came through and needs a manual refund
+			fmt.Printf("[PaymentService] payment %s did not settle auction %s\n", reference, payment.AuctionID)
+			return nil
+		}
+		return err
+	}
+
+	fmt.Printf("[PaymentService] auction %s settled by payment %s\n", payment.AuctionID, reference)
+	return nil
+}
+
+// toMinorUnits converts an amount to the pesewas/kobo that Paystack expects
+func toMinorUnits(amount decimal.Decimal) int64 {
+	return amount.Shift(2).Round(0).IntPart()
+}
@@ -173,11 +173,18 @@ func (s *ProductService) GetProductById(ctx context.Context, accessToken string,
 	return s.productRepo.GetProductById(ctx, accessToken, productID)
 }
 
-func (s *ProductService) GetProductWithAuction(ctx context.Context, productID uuid.UUID) (*models.ProductResponse, error) {
+// GetProductWithAuction loads a product with its auction. The auction's reserve is only shown to
+// the owner and admins; viewer is nil for guests
+func (s *ProductService) GetProductWithAuction(ctx context.Context, productID uuid.UUID, viewer *models.User) (*models.ProductResponse, error) {
 	if productID == uuid.Nil {
 		return nil, constants.ErrInvalidID
 	}
-	return s.productRepo.GetProductWithAuction(ctx, productID)
+	res, err := s.productRepo.GetProductWithAuction(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	res.Auction.PresentTo(viewer, res.Product.OwnerID)
+	return res, nil
 }
 func (s *ProductService) GetProductsByOwner(ctx context.Context, accessToken string, ownerID uuid.UUID, limit, offset int) ([]*models.Product, int64, error) {
 	return s.productRepo.GetProductsByOwner(ctx, accessToken, ownerID, limit, offset)
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/joshua-takyi/auction/internal/constants"
+	"github.com/joshua-takyi/auction/internal/models"
+)
+
+// relistBatchSize caps how many unsold auctions a single worker tick relists
+const relistBatchSize = 50
+
+// RelistService puts unsold auctions up again when the seller opted into a relist rule. Each
+// attempt is created through the same validation as a seller's own listing and points back at
+// the attempt before it, so the whole history can be followed from the latest auction
+type RelistService struct {
+	auctionRepo    models.AuctionInterface
+	auctionService *AuctionService
+	notifService   *NotificationService
+	logger         *slog.Logger
+}
+
+func NewRelistService(auctionRepo models.AuctionInterface, auctionService *AuctionService, notifService *NotificationService, logger *slog.Logger) *RelistService {
+	return &RelistService{
+		auctionRepo:    auctionRepo,
+		auctionService: auctionService,
+		notifService:   notifService,
+		logger:         logger,
+	}
+}
+
+// RelistUnsoldAuctions relists a batch of unsold auctions and returns how many it relisted
+func (s *RelistService) RelistUnsoldAuctions(ctx context.Context) (int, error) {
+	auctions, err := s.auctionRepo.GetRelistCandidates(ctx, relistBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	relisted := 0
+	for _, auction := range auctions {
+		// A lot can settle without a single winner and still have sold units
+		if auction.Product.Status != constants.ProductApproved {
+			s.dropRule(ctx, auction.ID)
+			continue
+		}
+		if err := s.relist(ctx, auction); err != nil {
+			if err != constants.ErrNoData {
+				s.logger.Error("Failed to relist auction", "auction_id", auction.ID, "error", err)
+			}
+			continue
+		}
+		relisted++
+	}
+	return relisted, nil
+}
+
+// relist claims the unsold auction first so two workers can't both relist it, and gives the
+// claim back if the new attempt can't be created
+func (s *RelistService) relist(ctx context.Context, auction *models.AuctionResponse) error {
+	now := time.Now()
+	if err := s.auctionRepo.MarkRelisted(ctx, auction.ID, &now); err != nil {
+		return err
+	}
+
+	created, err := s.auctionService.createAuction(ctx, auction.Relist(now), "", auction.ProductID)
+	if err != nil {
+		if releaseErr := s.auctionRepo.MarkRelisted(ctx, auction.ID, nil); releaseErr != nil {
+			s.logger.Error("Failed to release relist claim", "auction_id", auction.ID, "error", releaseErr)
+		}
+		// The seller has to step in when the terms no longer validate or the product has moved
+		// on; retrying every tick wouldn't change anything
+		if errors.Is(err, constants.ErrInvalidInput) || errors.Is(err, constants.ErrProductNotApproved) || errors.Is(err, constants.ErrProductHasActiveAuction) {
+			s.dropRule(ctx, auction.ID)
+		}
+		return err
+	}
+
+	s.notifService.NotifyAuctionRelisted(auction.Product.OwnerID.String(), auction.Product.Title, created.ID.String(), created.StartTime)
+	s.logger.Info("Auction relisted", "auction_id", auction.ID, "relist_id", created.ID, "attempt", created.RelistAttempt, "start_price", created.StartPrice)
+	return nil
+}
+
+// dropRule clears an auction's remaining relist attempts so it stops coming up as a candidate
+func (s *RelistService) dropRule(ctx context.Context, auctionID uuid.UUID) {
+	fields := map[string]any{
+		"relist_attempts": 0,
+		"updated_at":      time.Now(),
+	}
+	if _, err := s.auctionRepo.UpdateAuction(ctx, fields, "", auctionID); err != nil {
+		s.logger.Error("Failed to clear relist rule", "auction_id", auctionID, "error", err)
+	}
+}
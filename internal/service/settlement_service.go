@@ -0,0 +1,416 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/joshua-takyi/auction/internal/constants"
+	"github.com/joshua-takyi/auction/internal/models"
+	"github.com/shopspring/decimal"
+)
+
+// settlementBatchSize caps how many ended auctions a single worker tick settles
+const settlementBatchSize = 50
+
+// SettlementService turns ENDED auctions into a final result. Unsold auctions move straight to
+// SETTLED; won auctions record their winner and stay ENDED until PaymentService verifies the
+// charge. Every step can be repeated: the result is recomputed from the bids, the product update
+// is a plain overwrite and an auction is only resolved once, so a crashed run is simply picked
+// up on the next tick.
+type SettlementService struct {
+	auctionRepo      models.AuctionInterface
+	bidRepo          models.BidInterface
+	sealedBidRepo    models.SealedBidInterface
+	lotRepo          models.LotInterface
+	productRepo      models.ProductInterface
+	stateMachine     *AuctionStateMachine
+	notifService     *NotificationService
+	logger           *slog.Logger
+	paymentDeadline  time.Duration
+	paymentReminders []time.Duration // time before the deadline, longest first
+}
+
+func NewSettlementService(auctionRepo models.AuctionInterface, bidRepo models.BidInterface, sealedBidRepo models.SealedBidInterface, lotRepo models.LotInterface, productRepo models.ProductInterface, stateMachine *AuctionStateMachine, notifService *NotificationService, logger *slog.Logger, paymentDeadline time.Duration, paymentReminders []time.Duration) *SettlementService {
+	return &SettlementService{
+		auctionRepo:      auctionRepo,
+		bidRepo:          bidRepo,
+		sealedBidRepo:    sealedBidRepo,
+		lotRepo:          lotRepo,
+		productRepo:      productRepo,
+		stateMachine:     stateMachine,
+		notifService:     notifService,
+		logger:           logger,
+		paymentDeadline:  paymentDeadline,
+		paymentReminders: paymentReminders,
+	}
+}
+
+// SettleEndedAuctions settles a batch of ENDED auctions and returns how many it settled
+func (s *SettlementService) SettleEndedAuctions(ctx context.Context) (int, error) {
+	auctions, err := s.auctionRepo.GetUnresolvedAuctions(ctx, settlementBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	settled := 0
+	for _, auction := range auctions {
+		if err := s.SettleAuction(ctx, auction); err != nil {
+			if err == constants.ErrNoData {
+				// Already settled by an earlier run
+				continue
+			}
+			s.logger.Error("Failed to settle auction", "auction_id", auction.ID, "error", err)
+			continue
+		}
+		settled++
+	}
+	return settled, nil
+}
+
+// SettleAuction determines the winner of a single ended auction, updates the product and
+// notifies every bidder of the outcome
+func (s *SettlementService) SettleAuction(ctx context.Context, auction *models.AuctionResponse) error {
+	if auction.Type == models.AuctionMultiUnit {
+		return s.settleLot(ctx, auction)
+	}
+
+	bids, err := auctionBids(ctx, s.bidRepo, s.sealedBidRepo, s.lotRepo, &auction.Auction)
+	if err != nil {
+		return err
+	}
+
+	winnerID, finalPrice := determineWinner(&auction.Auction, bids)
+
+	productStatus := constants.ProductApproved
+	if winnerID != nil {
+		productStatus = constants.ProductSold
+	}
+	if err := s.productRepo.UpdateProductStatus(ctx, auction.ProductID, productStatus); err != nil {
+		return fmt.Errorf("failed to update product status: %w", err)
+	}
+
+	// Won auctions wait in ENDED for the winner's payment. In a reverse auction the winner is the
+	// supplier being paid, so the contract is awarded and the auction settles straight away
+	if winnerID != nil && auction.Type == models.AuctionReverse {
+		_, err := s.stateMachine.Transition(ctx, TransitionRequest{
+			AuctionID: auction.ID,
+			From:      constants.AuctionEnded,
+			To:        constants.AuctionSettled,
+			Trigger:   models.TriggerAwarded,
+			Guard:     models.TransitionGuard{IsNull: []string{"resolved_at"}},
+			Fields: map[string]any{
+				"winner_id":      *winnerID,
+				"current_bid":    finalPrice,
+				"resolved_at":    time.Now(),
+				"payment_due_at": nil,
+				"reminders_sent": 0,
+			},
+		})
+		if err != nil {
+			return err
+		}
+	} else if winnerID != nil {
+		dueAt := time.Now().Add(s.paymentDeadline)
+		if _, err := s.auctionRepo.ResolveAuction(ctx, auction.ID, *winnerID, finalPrice, dueAt); err != nil {
+			return err
+		}
+	} else {
+		_, err := s.stateMachine.Transition(ctx, TransitionRequest{
+			AuctionID: auction.ID,
+			From:      constants.AuctionEnded,
+			To:        constants.AuctionSettled,
+			Trigger:   models.TriggerUnsold,
+			Guard:     models.TransitionGuard{IsNull: []string{"resolved_at"}},
+			Fields: map[string]any{
+				"winner_id":      nil,
+				"current_bid":    finalPrice,
+				"resolved_at":    time.Now(),
+				"payment_due_at": nil,
+				"reminders_sent": 0,
+			},
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	s.notifyOutcome(auction, bids, winnerID)
+
+	if winnerID != nil && auction.Type == models.AuctionReverse {
+		s.logger.Info("Reverse auction awarded", "auction_id", auction.ID, "winner_id", *winnerID, "price", finalPrice)
+	} else if winnerID != nil {
+		s.logger.Info("Auction won, awaiting payment", "auction_id", auction.ID, "winner_id", *winnerID, "price", finalPrice)
+	} else {
+		s.logger.Info("Auction settled unsold", "auction_id", auction.ID, "bids", len(bids))
+	}
+	return nil
+}
+
+// settleLot allocates a multi-unit auction's units to the highest unit prices at a single
+// clearing price. A lot has no single winner, so winner_id stays empty and the allocations are
+// the result; the auction settles as soon as they are stored. Allocations are an upsert keyed on
+// the bidder, so a run that fails before the transition just writes the same rows again
+func (s *SettlementService) settleLot(ctx context.Context, auction *models.AuctionResponse) error {
+	bids, err := s.lotRepo.GetLotBids(ctx, auction.ID)
+	if err != nil {
+		return err
+	}
+
+	allocations, clearing := models.AllocateLot(auction.Quantity, bids, auction.LotMinimumPrice())
+	if err := s.lotRepo.CreateLotAllocations(ctx, allocations); err != nil {
+		return err
+	}
+
+	productStatus := constants.ProductApproved
+	trigger := models.TriggerUnsold
+	if len(allocations) > 0 {
+		productStatus = constants.ProductSold
+		trigger = models.TriggerLotCleared
+	}
+	if err := s.productRepo.UpdateProductStatus(ctx, auction.ProductID, productStatus); err != nil {
+		return fmt.Errorf("failed to update product status: %w", err)
+	}
+
+	_, err = s.stateMachine.Transition(ctx, TransitionRequest{
+		AuctionID: auction.ID,
+		From:      constants.AuctionEnded,
+		To:        constants.AuctionSettled,
+		Trigger:   trigger,
+		Guard:     models.TransitionGuard{IsNull: []string{"resolved_at"}},
+		Fields: map[string]any{
+			"winner_id":      nil,
+			"current_bid":    clearing,
+			"resolved_at":    time.Now(),
+			"payment_due_at": nil,
+			"reminders_sent": 0,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	title := auction.Product.Title
+	won := make(map[uuid.UUID]bool, len(allocations))
+	for _, a := range allocations {
+		won[a.BidderID] = true
+		s.notifService.NotifyLotWon(a.BidderID.String(), title, a.Quantity, a.Requested, clearing.String())
+	}
+	for _, b := range bids {
+		if !won[b.BidderID] {
+			s.notifService.NotifyAuctionLost(b.BidderID.String(), title)
+		}
+	}
+
+	if len(allocations) > 0 {
+		s.logger.Info("Lot cleared", "auction_id", auction.ID, "winners", len(allocations), "clearing_price", clearing)
+	} else {
+		s.logger.Info("Auction settled unsold", "auction_id", auction.ID, "bids", len(bids))
+	}
+	return nil
+}
+
+func (s *SettlementService) notifyOutcome(auction *models.AuctionResponse, bids []*models.Bid, winnerID *uuid.UUID) {
+	title := auction.Product.Title
+	// Buy It Now winners were already told when they bought the lot
+	if winnerID != nil && auction.WinnerID == nil {
+		s.notifService.NotifyAuctionWon(winnerID.String(), title)
+	}
+
+	notified := make(map[uuid.UUID]bool)
+	for _, bid := range bids {
+		if notified[bid.BidBy] || (winnerID != nil && bid.BidBy == *winnerID) {
+			continue
+		}
+		notified[bid.BidBy] = true
+		s.notifService.NotifyAuctionLost(bid.BidBy.String(), title)
+	}
+}
+
+// determineWinner picks the best valid bid and checks it against the reserve. A winner
+// recorded before the auction ended (Buy It Now) is kept as is. The winner pays their own bid,
+// except in a second-price auction where they pay the clearing price
+func determineWinner(auction *models.Auction, bids []*models.Bid) (*uuid.UUID, decimal.Decimal) {
+	if auction.WinnerID != nil {
+		return auction.WinnerID, auction.CurrentBid
+	}
+
+	bid, missedReserve := bestEligibleBid(auction, bids, nil)
+	if bid == nil {
+		if missedReserve != nil {
+			return nil, missedReserve.BidAmount
+		}
+		return nil, auction.CurrentBid
+	}
+	winner := bid.BidBy
+	if auction.Type == models.AuctionSealedSecondPrice {
+		return &winner, secondPrice(auction, bids, bid)
+	}
+	return &winner, bid.BidAmount
+}
+
+// secondPrice is the clearing price of a Vickrey auction: the highest bid from anyone other than
+// the winner, raised to the start price and reserve when it falls below them
+func secondPrice(auction *models.Auction, bids []*models.Bid, winning *models.Bid) decimal.Decimal {
+	price := auction.StartPrice
+	if auction.ReservePrice != nil {
+		price = decimal.Max(price, *auction.ReservePrice)
+	}
+	for _, b := range bids {
+		if b.BidBy != winning.BidBy {
+			price = decimal.Max(price, b.BidAmount)
+			break
+		}
+	}
+	return decimal.Min(price, winning.BidAmount)
+}
+
+// bestEligibleBid returns the best bid within the start price from a bidder not in exclude,
+// provided it meets the reserve. The best bid is the highest one, or the lowest in a reverse
+// auction where the start price and reserve are ceilings rather than floors. When the best
+// candidate misses the reserve it is returned as missedReserve instead, since nothing worse can
+// win either. Bids are expected best first, earliest first among equal amounts
+func bestEligibleBid(auction *models.Auction, bids []*models.Bid, exclude map[uuid.UUID]bool) (bid *models.Bid, missedReserve *models.Bid) {
+	reverse := auction.Type == models.AuctionReverse
+	for _, b := range bids {
+		if exclude[b.BidBy] {
+			continue
+		}
+		if reverse {
+			if b.BidAmount.GreaterThan(auction.StartPrice) {
+				continue
+			}
+			if auction.ReservePrice != nil && b.BidAmount.GreaterThan(*auction.ReservePrice) {
+				return nil, b
+			}
+			return b, nil
+		}
+		if b.BidAmount.LessThan(auction.StartPrice) {
+			continue
+		}
+		if auction.ReservePrice != nil && b.BidAmount.LessThan(*auction.ReservePrice) {
+			return nil, b
+		}
+		return b, nil
+	}
+	return nil, nil
+}
+
+// EnforcePaymentDeadlines reminds winners who haven't paid yet and, once their deadline has
+// passed, cancels their win and makes a second chance offer to the next-highest bidder
+func (s *SettlementService) EnforcePaymentDeadlines(ctx context.Context) (reminded int, lapsed int, err error) {
+	auctions, err := s.auctionRepo.GetAwaitingPaymentAuctions(ctx, settlementBatchSize)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	now := time.Now()
+	for _, auction := range auctions {
+		if auction.WinnerID == nil || auction.PaymentDueAt == nil {
+			continue
+		}
+
+		if now.After(*auction.PaymentDueAt) {
+			if err := s.lapseWinner(ctx, auction); err != nil {
+				if err != constants.ErrNoData {
+					s.logger.Error("Failed to cancel unpaid win", "auction_id", auction.ID, "error", err)
+				}
+				continue
+			}
+			lapsed++
+			continue
+		}
+
+		sent, err := s.sendPaymentReminder(ctx, auction, now)
+		if err != nil {
+			if err != constants.ErrNoData {
+				s.logger.Error("Failed to send payment reminder", "auction_id", auction.ID, "error", err)
+			}
+			continue
+		}
+		if sent {
+			reminded++
+		}
+	}
+	return reminded, lapsed, nil
+}
+
+// sendPaymentReminder sends at most one reminder per call: the latest one that is due. Earlier
+// reminders that were missed (e.g. while the worker was down) are skipped rather than sent in a burst
+func (s *SettlementService) sendPaymentReminder(ctx context.Context, auction *models.AuctionResponse, now time.Time) (bool, error) {
+	due := auction.RemindersSent
+	for i := auction.RemindersSent; i < len(s.paymentReminders); i++ {
+		if now.Before(auction.PaymentDueAt.Add(-s.paymentReminders[i])) {
+			break
+		}
+		due = i + 1
+	}
+	if due == auction.RemindersSent {
+		return false, nil
+	}
+
+	// Record first so a second worker can't send the same reminder
+	if err := s.auctionRepo.MarkReminderSent(ctx, auction.ID, due, auction.RemindersSent); err != nil {
+		return false, err
+	}
+
+	s.notifService.NotifyPaymentReminder(auction.WinnerID.String(), auction.ID.String(), auction.Product.Title, *auction.PaymentDueAt)
+	return true, nil
+}
+
+// lapseWinner cancels the current winner's unpaid win and offers the lot to the next-highest
+// bidder at their last bid. With nobody left to offer it to, the auction closes unsold
+func (s *SettlementService) lapseWinner(ctx context.Context, auction *models.AuctionResponse) error {
+	previousWinner := *auction.WinnerID
+
+	bids, err := auctionBids(ctx, s.bidRepo, s.sealedBidRepo, s.lotRepo, &auction.Auction)
+	if err != nil {
+		return err
+	}
+
+	lapsed := append([]uuid.UUID{}, auction.LapsedWinnerIDs...)
+	lapsed = append(lapsed, previousWinner)
+	exclude := make(map[uuid.UUID]bool, len(lapsed))
+	for _, id := range lapsed {
+		exclude[id] = true
+	}
+
+	next, _ := bestEligibleBid(&auction.Auction, bids, exclude)
+	if next == nil {
+		if err := s.productRepo.UpdateProductStatus(ctx, auction.ProductID, constants.ProductApproved); err != nil {
+			return fmt.Errorf("failed to update product status: %w", err)
+		}
+		_, err := s.stateMachine.Transition(ctx, TransitionRequest{
+			AuctionID: auction.ID,
+			From:      constants.AuctionEnded,
+			To:        constants.AuctionSettled,
+			Trigger:   models.TriggerPaymentLapse,
+			Guard:     models.TransitionGuard{Eq: map[string]string{"winner_id": previousWinner.String()}},
+			Fields: map[string]any{
+				"winner_id":         nil,
+				"payment_due_at":    nil,
+				"reminders_sent":    0,
+				"lapsed_winner_ids": lapsed,
+			},
+		})
+		if err != nil {
+			return err
+		}
+		s.notifService.NotifyPaymentExpired(previousWinner.String(), auction.Product.Title)
+		s.logger.Info("Unpaid auction closed unsold", "auction_id", auction.ID, "lapsed_winner_id", previousWinner)
+		return nil
+	}
+
+	dueAt := time.Now().Add(s.paymentDeadline)
+	nextWinner := next.BidBy
+	if _, err := s.auctionRepo.ReassignWinner(ctx, auction.ID, previousWinner, nextWinner, next.BidAmount, dueAt, lapsed); err != nil {
+		return err
+	}
+
+	s.notifService.NotifyPaymentExpired(previousWinner.String(), auction.Product.Title)
+	s.notifService.NotifySecondChanceOffer(nextWinner.String(), auction.ID.String(), auction.Product.Title, next.BidAmount.String(), dueAt)
+	s.logger.Info("Second chance offer made", "auction_id", auction.ID, "lapsed_winner_id", previousWinner, "winner_id", nextWinner, "price", next.BidAmount)
+	return nil
+}
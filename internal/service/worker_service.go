@@ -3,32 +3,57 @@ package service
 import (
 	"context"
 	"log/slog"
+	"sync"
 	"time"
 )
 
 type WorkerService struct {
-	auctionService *AuctionService
-	logger         *slog.Logger
-	stopChan       chan struct{}
+	auctionService    *AuctionService
+	settlementService *SettlementService
+	relistService     *RelistService
+	noticeService     *AuctionNoticeService
+	elector           *LeaderElector
+	logger            *slog.Logger
+	stopChan          chan struct{}
+	stopOnce          sync.Once
+	done              chan struct{} // closed once the loop has exited
+
+	// runCtx is the parent of every run's context, so Stop can cut off a run that is still going
+	// when the shutdown deadline hits
+	runCtx    context.Context
+	cancelRun context.CancelFunc
 }
 
-func NewWorkerService(auctionService *AuctionService, logger *slog.Logger) *WorkerService {
+func NewWorkerService(auctionService *AuctionService, settlementService *SettlementService, relistService *RelistService, noticeService *AuctionNoticeService, elector *LeaderElector, logger *slog.Logger) *WorkerService {
+	runCtx, cancelRun := context.WithCancel(context.Background())
 	return &WorkerService{
-		auctionService: auctionService,
-		logger:         logger,
-		stopChan:       make(chan struct{}),
+		auctionService:    auctionService,
+		settlementService: settlementService,
+		relistService:     relistService,
+		noticeService:     noticeService,
+		elector:           elector,
+		logger:            logger,
+		stopChan:          make(chan struct{}),
+		done:              make(chan struct{}),
+		runCtx:            runCtx,
+		cancelRun:         cancelRun,
 	}
 }
 
+// Start runs the jobs every interval on whichever instance currently leads, so scaling out
+// doesn't mean several workers racing over the same auctions
 func (w *WorkerService) Start(interval time.Duration) {
 	ticker := time.NewTicker(interval)
 	w.logger.Info("Auction status worker started", "interval", interval)
 
 	go func() {
+		defer close(w.done)
 		for {
 			select {
 			case <-ticker.C:
-				w.RunUpdate()
+				if w.elector.IsLeader() {
+					w.RunUpdate()
+				}
 			case <-w.stopChan:
 				ticker.Stop()
 				return
@@ -38,26 +63,68 @@ func (w *WorkerService) Start(interval time.Duration) {
 }
 
 func (w *WorkerService) RunUpdate() {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(w.runCtx, 30*time.Second)
 	defer cancel()
 
-	result, err := w.auctionService.UpdateAuctionStatuses(ctx)
+	scheduledToLive, liveToEnded, err := w.auctionService.AdvanceAuctions(ctx)
 	if err != nil {
 		w.logger.Error("Failed to update auction statuses", "error", err)
 		return
 	}
 
-	scheduledToLive := result["scheduled_to_live"].(float64)
-	liveToEnded := result["live_to_ended"].(float64)
-
 	if scheduledToLive > 0 || liveToEnded > 0 {
 		w.logger.Info("Auction statuses updated",
 			"scheduled_to_live", scheduledToLive,
 			"live_to_ended", liveToEnded,
 		)
 	}
+
+	notified, err := w.noticeService.SendEndingSoonNotices(ctx)
+	if err != nil {
+		// Notices are best effort, so settlement still runs
+		w.logger.Error("Failed to send ending soon notices", "error", err)
+	} else if notified > 0 {
+		w.logger.Info("Ending soon notices sent", "auctions", notified)
+	}
+
+	settled, err := w.settlementService.SettleEndedAuctions(ctx)
+	if err != nil {
+		w.logger.Error("Failed to settle ended auctions", "error", err)
+		return
+	}
+	if settled > 0 {
+		w.logger.Info("Ended auctions settled", "settled", settled)
+	}
+
+	reminded, lapsed, err := w.settlementService.EnforcePaymentDeadlines(ctx)
+	if err != nil {
+		w.logger.Error("Failed to enforce payment deadlines", "error", err)
+		return
+	}
+	if reminded > 0 || lapsed > 0 {
+		w.logger.Info("Payment deadlines enforced", "reminded", reminded, "lapsed", lapsed)
+	}
+
+	relisted, err := w.relistService.RelistUnsoldAuctions(ctx)
+	if err != nil {
+		w.logger.Error("Failed to relist unsold auctions", "error", err)
+		return
+	}
+	if relisted > 0 {
+		w.logger.Info("Unsold auctions relisted", "relisted", relisted)
+	}
 }
 
-func (w *WorkerService) Stop() {
-	close(w.stopChan)
+// Stop stops the ticker and waits for a run that is in progress to finish. If ctx is done first
+// the run is cancelled and ctx's error returned. Start must have been called
+func (w *WorkerService) Stop(ctx context.Context) error {
+	w.stopOnce.Do(func() { close(w.stopChan) })
+
+	select {
+	case <-w.done:
+		return nil
+	case <-ctx.Done():
+		w.cancelRun()
+		return ctx.Err()
+	}
 }
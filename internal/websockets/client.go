@@ -2,6 +2,8 @@ package websockets
 
 import (
 	"log"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/websocket"
@@ -25,15 +27,28 @@ const (
 type Client struct {
 	ConnID     string          // Unique connection identifier (exported for setting)
 	UserID     string          // ID of the user associated with this connection
-	RoomID     string          // Auction room this client is participating in
 	connection *websocket.Conn // Underlying WebSocket connection
 	manager    *Manager        // Reference to the manager handling this client
 	send       chan []byte     // Buffered channel for outbound messages
+
+	closeMessage []byte      // close frame payload written once send is closed; set before closing
+	dropping     atomic.Bool // set once the client has been handed to the run loop for falling behind
+
+	mu     sync.Mutex
+	rooms  map[string]bool // Auction rooms this client is subscribed to
+	closed bool            // set once the manager has removed the client
+
+	resume *pendingResume // room to rejoin from the connection URL, handled once registered
+}
+
+type pendingResume struct {
+	roomID string
+	from   *resumePoint
 }
 
 func (c *Client) readPump() {
 	defer func() {
-		c.manager.unregister <- c
+		c.manager.drop(c)
 		c.connection.Close()
 	}()
 
@@ -45,6 +60,10 @@ func (c *Client) readPump() {
 	})
 	c.connection.SetReadLimit(maxMessageSize)
 
+	if c.resume != nil {
+		c.resumeRoom(c.resume.roomID, c.resume.from)
+	}
+
 	for {
 		_, message, err := c.connection.ReadMessage()
 		if err != nil {
@@ -54,8 +73,7 @@ func (c *Client) readPump() {
 			}
 			break
 		}
-		// Forward message to manager for broadcasting to clients in the same room
-		c.manager.broadcastToRoom(c.RoomID, message)
+		c.dispatch(message)
 	}
 }
 
@@ -72,7 +90,11 @@ func (c *Client) writePump() {
 			c.connection.SetWriteDeadline(time.Now().Add(writeWait))
 			if !ok {
 				// Channel closed - send close message and exit
-				c.connection.WriteMessage(websocket.CloseMessage, []byte{})
+				closeMessage := c.closeMessage
+				if closeMessage == nil {
+					closeMessage = []byte{}
+				}
+				c.connection.WriteMessage(websocket.CloseMessage, closeMessage)
 				return
 			}
 
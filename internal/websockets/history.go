@@ -0,0 +1,166 @@
+package websockets
+
+import (
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// historySize is how many recent messages a room keeps for replay. It stays well under a
+	// client's send buffer so a full replay can't overflow it
+	historySize = 128
+	// historyTTL is how long a room nobody is in keeps its history
+	historyTTL = 15 * time.Minute
+	// historySweepInterval is how often idle room histories are dropped
+	historySweepInterval = time.Minute
+)
+
+// roomLog numbers a room's messages and keeps the latest ones. Sequence numbers only mean
+// something within one epoch: a room that was dropped, or a server that restarted, starts a new
+// epoch and clients holding the old one have to refresh
+type roomLog struct {
+	mu       sync.Mutex
+	epoch    string
+	seq      uint64
+	ring     [historySize][]byte // stamped message for seq at ring[seq%historySize]
+	lastUsed time.Time
+}
+
+// since returns the messages after lastSeq in order, or false when some of them are no longer
+// kept. Callers hold mu
+func (l *roomLog) since(lastSeq uint64) ([][]byte, bool) {
+	if lastSeq > l.seq || l.seq-lastSeq > historySize {
+		return nil, false
+	}
+	missed := make([][]byte, 0, l.seq-lastSeq)
+	for seq := lastSeq + 1; seq <= l.seq; seq++ {
+		missed = append(missed, l.ring[seq%historySize])
+	}
+	return missed, true
+}
+
+// resumePoint is the last message a client saw in a room
+type resumePoint struct {
+	Epoch   string `json:"epoch"`
+	LastSeq uint64 `json:"last_seq"`
+}
+
+// roomPosition tells a client where a room's stream is after it joined, so it knows what to
+// resume from next time
+type roomPosition struct {
+	RoomID          string   `json:"room_id"`
+	Epoch           string   `json:"epoch"`
+	Seq             uint64   `json:"seq"`
+	Replayed        int      `json:"replayed"`
+	RefreshRequired bool     `json:"refresh_required"`
+	Rooms           []string `json:"rooms,omitempty"`
+}
+
+// roomLog returns the room's log, starting a new epoch when it has none
+func (manager *Manager) roomLog(roomID string) *roomLog {
+	manager.historyMu.Lock()
+	defer manager.historyMu.Unlock()
+	rl, ok := manager.history[roomID]
+	if !ok {
+		rl = &roomLog{epoch: uuid.NewString()[:8], lastUsed: time.Now()}
+		manager.history[roomID] = rl
+	}
+	return rl
+}
+
+// publishToRoom stamps a message with the room's next sequence number, keeps it for replay and
+// fans it out. The room's log stays locked throughout so every client sees the room's messages
+// in sequence order
+func (manager *Manager) publishToRoom(roomID string, data []byte) {
+	rl := manager.roomLog(roomID)
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	stamped, err := stamp(data, roomID, rl.epoch, rl.seq+1)
+	if err != nil {
+		log.Printf("[WEBSOCKET] failed to stamp message for room %s: %v", roomID, err)
+		return
+	}
+	rl.seq++
+	rl.ring[rl.seq%historySize] = stamped
+	rl.lastUsed = time.Now()
+
+	manager.rooms.each(roomID, func(client *Client) {
+		manager.deliver(client, stamped)
+	})
+}
+
+// join subscribes a client to a room. When from is set and the room still has everything after
+// it, the missed messages are sent first; otherwise the position asks for a full refresh. It
+// reports false when the client is at its room limit
+func (manager *Manager) join(client *Client, roomID string, from *resumePoint) (roomPosition, bool) {
+	rl := manager.roomLog(roomID)
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	// Subscribing under the log's lock means nothing is published between the replay and
+	// the first live message
+	if !manager.subscribe(client, roomID) {
+		return roomPosition{}, false
+	}
+
+	pos := roomPosition{RoomID: roomID, Epoch: rl.epoch, Seq: rl.seq}
+	if from == nil {
+		return pos, true
+	}
+
+	var missed [][]byte
+	ok := from.Epoch == rl.epoch
+	if ok {
+		missed, ok = rl.since(from.LastSeq)
+	}
+	if !ok {
+		pos.RefreshRequired = true
+		return pos, true
+	}
+	for _, msg := range missed {
+		manager.sendToClient(client, msg)
+	}
+	pos.Replayed = len(missed)
+	return pos, true
+}
+
+// sweepHistory drops the history of rooms nobody has been in or published to for historyTTL
+func (manager *Manager) sweepHistory() {
+	manager.historyMu.Lock()
+	defer manager.historyMu.Unlock()
+	for roomID, rl := range manager.history {
+		if manager.rooms.count(roomID) > 0 {
+			continue
+		}
+		rl.mu.Lock()
+		idle := time.Since(rl.lastUsed) > historyTTL
+		rl.mu.Unlock()
+		if idle {
+			delete(manager.history, roomID)
+		}
+	}
+}
+
+// stamp adds a room's sequence fields to a JSON object message
+func stamp(data []byte, roomID, epoch string, seq uint64) ([]byte, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	var err error
+	if fields["room_id"], err = json.Marshal(roomID); err != nil {
+		return nil, err
+	}
+	if fields["epoch"], err = json.Marshal(epoch); err != nil {
+		return nil, err
+	}
+	if fields["seq"], err = json.Marshal(seq); err != nil {
+		return nil, err
+	}
+	return json.Marshal(fields)
+}
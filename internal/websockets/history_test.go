@@ -0,0 +1,167 @@
+package websockets
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// publishN publishes n numbered messages to a room nobody is in, so they only land in its history
+func publishN(m *Manager, roomID string, n int) {
+	for i := range n {
+		m.publishToRoom(roomID, fmt.Appendf(nil, `{"n":%d}`, i+1))
+	}
+}
+
+// replayedSeqs reads every message waiting on a client and returns their sequence numbers,
+// failing if any is stamped for another room or epoch
+func replayedSeqs(t *testing.T, client *Client, roomID, epoch string) []uint64 {
+	t.Helper()
+	var seqs []uint64
+	for len(client.send) > 0 {
+		var msg struct {
+			RoomID string `json:"room_id"`
+			Epoch  string `json:"epoch"`
+			Seq    uint64 `json:"seq"`
+		}
+		if err := json.Unmarshal(<-client.send, &msg); err != nil {
+			t.Fatalf("unmarshal replayed message: %v", err)
+		}
+		if msg.RoomID != roomID || msg.Epoch != epoch {
+			t.Fatalf("replayed message stamped %s/%s, want %s/%s", msg.RoomID, msg.Epoch, roomID, epoch)
+		}
+		seqs = append(seqs, msg.Seq)
+	}
+	return seqs
+}
+
+// TestJoinResume joins a room with a resume point at different distances behind the room's
+// latest message and checks what is replayed and when a refresh is asked for instead
+func TestJoinResume(t *testing.T) {
+	tests := []struct {
+		name        string
+		published   int
+		noResume    bool
+		staleEpoch  bool
+		lastSeq     uint64
+		wantReplay  int
+		wantRefresh bool
+	}{
+		{name: "no resume point", published: 5, noResume: true},
+		{name: "empty room", published: 0, lastSeq: 0},
+		{name: "empty gap", published: 5, lastSeq: 5},
+		{name: "short gap", published: 5, lastSeq: 2, wantReplay: 3},
+		{name: "full ring from the start", published: historySize, lastSeq: 0, wantReplay: historySize},
+		{name: "exact full ring after wrapping", published: 300, lastSeq: 300 - historySize, wantReplay: historySize},
+		{name: "one past the ring", published: 300, lastSeq: 300 - historySize - 1, wantRefresh: true},
+		{name: "far past the ring", published: 300, lastSeq: 0, wantRefresh: true},
+		{name: "ahead of the room", published: 5, lastSeq: 9, wantRefresh: true},
+		{name: "stale epoch", published: 5, lastSeq: 5, staleEpoch: true, wantRefresh: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewManager()
+			roomID := uuid.NewString()
+			publishN(m, roomID, tt.published)
+			epoch := m.roomLog(roomID).epoch
+
+			var from *resumePoint
+			if !tt.noResume {
+				from = &resumePoint{Epoch: epoch, LastSeq: tt.lastSeq}
+				if tt.staleEpoch {
+					from.Epoch = "stale"
+				}
+			}
+
+			client := newTestClient(m, "user-1", 2*historySize)
+			pos, ok := m.join(client, roomID, from)
+			if !ok {
+				t.Fatal("join refused")
+			}
+			if pos.RoomID != roomID || pos.Epoch != epoch || pos.Seq != uint64(tt.published) {
+				t.Errorf("position %s/%s/%d, want %s/%s/%d", pos.RoomID, pos.Epoch, pos.Seq, roomID, epoch, tt.published)
+			}
+			if pos.RefreshRequired != tt.wantRefresh {
+				t.Errorf("refresh_required = %v, want %v", pos.RefreshRequired, tt.wantRefresh)
+			}
+			if pos.Replayed != tt.wantReplay {
+				t.Errorf("replayed = %d, want %d", pos.Replayed, tt.wantReplay)
+			}
+
+			seqs := replayedSeqs(t, client, roomID, epoch)
+			if len(seqs) != tt.wantReplay {
+				t.Fatalf("client was sent %d messages, want %d", len(seqs), tt.wantReplay)
+			}
+			for i, seq := range seqs {
+				if want := tt.lastSeq + uint64(i) + 1; seq != want {
+					t.Fatalf("replayed message %d has seq %d, want %d", i, seq, want)
+				}
+			}
+
+			// Anything published after the join arrives live, straight after the replay
+			publishN(m, roomID, 1)
+			if seqs := replayedSeqs(t, client, roomID, epoch); len(seqs) != 1 || seqs[0] != pos.Seq+1 {
+				t.Errorf("live messages after join have seqs %v, want [%d]", seqs, pos.Seq+1)
+			}
+		})
+	}
+}
+
+// TestJoinAfterSweep checks a room whose history was swept starts a new epoch, so a client
+// resuming from the old one is told to refresh rather than replayed the wrong messages
+func TestJoinAfterSweep(t *testing.T) {
+	tests := []struct {
+		name        string
+		occupied    bool
+		idle        time.Duration
+		wantRefresh bool
+	}{
+		{name: "idle room is swept", idle: historyTTL + time.Minute, wantRefresh: true},
+		{name: "recently used room is kept", idle: historyTTL - time.Minute},
+		{name: "occupied room is kept", occupied: true, idle: historyTTL + time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewManager()
+			roomID := uuid.NewString()
+			if tt.occupied {
+				newTestClient(m, "user-0", 2*historySize, roomID)
+			}
+			publishN(m, roomID, 5)
+			rl := m.roomLog(roomID)
+			rl.mu.Lock()
+			oldEpoch := rl.epoch
+			rl.lastUsed = time.Now().Add(-tt.idle)
+			rl.mu.Unlock()
+
+			m.sweepHistory()
+
+			client := newTestClient(m, "user-1", 2*historySize)
+			pos, ok := m.join(client, roomID, &resumePoint{Epoch: oldEpoch, LastSeq: 3})
+			if !ok {
+				t.Fatal("join refused")
+			}
+			if pos.RefreshRequired != tt.wantRefresh {
+				t.Errorf("refresh_required = %v, want %v", pos.RefreshRequired, tt.wantRefresh)
+			}
+			if tt.wantRefresh {
+				if pos.Epoch == oldEpoch || pos.Seq != 0 || pos.Replayed != 0 {
+					t.Errorf("expected a new epoch from seq 0, got %s/%d replaying %d", pos.Epoch, pos.Seq, pos.Replayed)
+				}
+				if n := len(client.send); n != 0 {
+					t.Errorf("client was sent %d messages, want none", n)
+				}
+				return
+			}
+			if pos.Epoch != oldEpoch || pos.Seq != 5 || pos.Replayed != 2 {
+				t.Errorf("expected %s/5 replaying 2, got %s/%d replaying %d", oldEpoch, pos.Epoch, pos.Seq, pos.Replayed)
+			}
+			if seqs := replayedSeqs(t, client, roomID, oldEpoch); len(seqs) != 2 || seqs[0] != 4 || seqs[1] != 5 {
+				t.Errorf("replayed seqs %v, want [4 5]", seqs)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package websockets
+
+import (
+	"hash/maphash"
+	"sync"
+)
+
+// indexShards is how many independently locked shards a clientIndex is split into. Fan-out to
+// one hot room only holds that room's shard, so other rooms and users carry on around it
+const indexShards = 32
+
+type indexShard struct {
+	mu  sync.RWMutex
+	set map[string]map[*Client]bool
+}
+
+// clientIndex maps a key (room id or user id) to the clients under it, sharded by key
+type clientIndex struct {
+	seed   maphash.Seed
+	shards [indexShards]indexShard
+}
+
+func newClientIndex() *clientIndex {
+	idx := &clientIndex{seed: maphash.MakeSeed()}
+	for i := range idx.shards {
+		idx.shards[i].set = make(map[string]map[*Client]bool)
+	}
+	return idx
+}
+
+func (idx *clientIndex) shard(key string) *indexShard {
+	return &idx.shards[maphash.String(idx.seed, key)%indexShards]
+}
+
+func (idx *clientIndex) add(key string, client *Client) {
+	shard := idx.shard(key)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+	clients, ok := shard.set[key]
+	if !ok {
+		clients = make(map[*Client]bool)
+		shard.set[key] = clients
+	}
+	clients[client] = true
+}
+
+func (idx *clientIndex) remove(key string, client *Client) {
+	shard := idx.shard(key)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+	clients := shard.set[key]
+	delete(clients, client)
+	if len(clients) == 0 {
+		delete(shard.set, key)
+	}
+}
+
+// each calls fn for every client under key while holding the shard's read lock. A client is
+// only closed after it has been removed from every index, so fn can send to it safely
+func (idx *clientIndex) each(key string, fn func(*Client)) {
+	shard := idx.shard(key)
+	shard.mu.RLock()
+	defer shard.mu.RUnlock()
+	for client := range shard.set[key] {
+		fn(client)
+	}
+}
+
+func (idx *clientIndex) count(key string) int {
+	shard := idx.shard(key)
+	shard.mu.RLock()
+	defer shard.mu.RUnlock()
+	return len(shard.set[key])
+}
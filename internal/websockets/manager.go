@@ -1,9 +1,11 @@
 package websockets
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
+	"strconv"
 	"sync"
 	"time"
 
@@ -33,28 +35,63 @@ type Ticket struct {
 	ExpiresAt time.Time
 }
 
+// restartMessage is the close frame clients get when the server shuts down, so they know to
+// reconnect rather than treat it as an error
+var restartMessage = websocket.FormatCloseMessage(websocket.CloseServiceRestart, "server restarting")
+
+// maxRoomsPerClient caps how many auction rooms one connection can follow
+const maxRoomsPerClient = 50
+
+// Manager tracks every connection. Only the run loop adds or removes clients. Connections are
+// indexed by room and by user in sharded indexes, so a broadcast only visits the connections it
+// is meant for and only locks the shard they are in; senders fan out on their own goroutine
+// rather than queueing behind one loop.
+//
+// A client's send channel is closed exactly once, by removeClient, and only after the client has
+// left clients and both indexes. Every send happens under the read lock of one of those, so
+// nothing can send on a closed channel
 type Manager struct {
 	clients    map[*Client]bool
+	mu         sync.RWMutex // guards clients
+	rooms      *clientIndex // room id -> subscribed clients
+	users      *clientIndex // user id -> that user's connections
 	register   chan *Client
-	mu         sync.RWMutex
 	unregister chan *Client
-	broadcast  chan Message
-	tickets    map[string]Ticket
+	handlers   map[CommandType]CommandHandler
+
+	ticketMu sync.Mutex
+	tickets  map[string]Ticket
+
+	historyMu sync.Mutex
+	history   map[string]*roomLog // room id -> sequence and recent messages
+
+	done     chan struct{} // closed when Stop is called
+	stopOnce sync.Once
+	stopped  chan struct{}  // closed when the run loop has exited
+	closed   []*Client      // clients still connected when the run loop exited
+	pumps    sync.WaitGroup // running write pumps
 }
 
 func NewManager() *Manager {
-	return &Manager{
+	m := &Manager{
 		clients:    make(map[*Client]bool),
+		rooms:      newClientIndex(),
+		users:      newClientIndex(),
 		register:   make(chan *Client),
-		broadcast:  make(chan Message),
 		unregister: make(chan *Client),
 		tickets:    make(map[string]Ticket),
+		history:    make(map[string]*roomLog),
+		handlers:   make(map[CommandType]CommandHandler),
+		done:       make(chan struct{}),
+		stopped:    make(chan struct{}),
 	}
+	m.registerBuiltinCommands()
+	return m
 }
 
 func (m *Manager) CreateTicket(userID string) string {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+	m.ticketMu.Lock()
+	defer m.ticketMu.Unlock()
 
 	ticket := uuid.New().String()
 	m.tickets[ticket] = Ticket{
@@ -65,8 +102,8 @@ func (m *Manager) CreateTicket(userID string) string {
 }
 
 func (m *Manager) ConsumeTicket(ticketID string) (string, bool) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+	m.ticketMu.Lock()
+	defer m.ticketMu.Unlock()
 
 	ticket, ok := m.tickets[ticketID]
 	if !ok {
@@ -84,45 +121,130 @@ func (m *Manager) ConsumeTicket(ticketID string) (string, bool) {
 
 func (manager *Manager) Start() {
 	go func() {
+		defer close(manager.stopped)
+		sweep := time.NewTicker(historySweepInterval)
+		defer sweep.Stop()
 		for {
 			select {
 			case client := <-manager.register:
 				manager.mu.Lock()
-				manager.clients[client] = true
+				manager.addClient(client)
 				manager.mu.Unlock()
+				// Added here rather than in Run so every Add happens before Stop starts waiting
+				manager.pumps.Add(1)
+				go func() {
+					defer manager.pumps.Done()
+					client.writePump()
+				}()
+				go client.readPump()
 			case client := <-manager.unregister:
 				manager.mu.Lock()
-
-				if _, ok := manager.clients[client]; ok {
-					delete(manager.clients, client)
-					close(client.send)
-				}
+				manager.removeClient(client)
 				fmt.Printf("[WEBSOCKET] unregistered user %s\n", client.UserID)
 				manager.mu.Unlock()
 
-			case msg := <-manager.broadcast:
-				manager.mu.RLock()
-				for client := range manager.clients {
-					if msg.RoomID == "" || client.RoomID == msg.RoomID {
-						select {
-						case client.send <- msg.Data:
-							// Message queued successfully
-						default:
-							// Client's send channel is full - remove it
-							go func(c *Client) {
-								manager.unregister <- c
-							}(client)
-						}
-
-					}
-				}
-				manager.mu.RUnlock()
+			case <-sweep.C:
+				manager.sweepHistory()
+
+			case <-manager.done:
+				manager.closeAll()
+				return
 			}
 
 		}
 	}()
 }
 
+// closeAll removes every client and closes its send channel, so each write pump sends the
+// restart close frame and exits
+func (manager *Manager) closeAll() {
+	manager.mu.Lock()
+	defer manager.mu.Unlock()
+	for client := range manager.clients {
+		client.closeMessage = restartMessage
+		manager.removeClient(client)
+		manager.closed = append(manager.closed, client)
+	}
+}
+
+// addClient indexes a new client under its user and the rooms it starts in. Callers hold mu
+func (manager *Manager) addClient(client *Client) {
+	manager.clients[client] = true
+	manager.users.add(client.UserID, client)
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	for roomID := range client.rooms {
+		manager.rooms.add(roomID, client)
+	}
+}
+
+// removeClient drops a client from every index and then closes its send channel. Callers hold
+// mu. Marking the client closed under its own lock stops a racing subscribe from indexing it
+// again afterwards
+func (manager *Manager) removeClient(client *Client) {
+	if !manager.clients[client] {
+		return
+	}
+	delete(manager.clients, client)
+	manager.users.remove(client.UserID, client)
+
+	client.mu.Lock()
+	client.closed = true
+	for roomID := range client.rooms {
+		manager.rooms.remove(roomID, client)
+	}
+	client.mu.Unlock()
+
+	close(client.send)
+}
+
+// drop hands a client to the run loop for removal. Once the manager is stopping the run loop
+// has already removed it
+func (manager *Manager) drop(client *Client) {
+	select {
+	case manager.unregister <- client:
+	case <-manager.done:
+	}
+}
+
+// publish fans a message out to its room, or to every client when it has no room. Only room
+// messages are numbered and kept for replay
+func (manager *Manager) publish(msg Message) {
+	if msg.RoomID != "" {
+		manager.publishToRoom(msg.RoomID, msg.Data)
+		return
+	}
+
+	manager.mu.RLock()
+	defer manager.mu.RUnlock()
+	for client := range manager.clients {
+		manager.deliver(client, msg.Data)
+	}
+}
+
+// deliver queues data for a client, dropping the client when it has fallen too far behind to
+// keep up. Callers hold a lock that keeps the client from being closed. Only the first full
+// send starts a drop, so a slow client in a busy room doesn't pile up a goroutine per message
+func (manager *Manager) deliver(client *Client, data []byte) {
+	select {
+	case client.send <- data:
+	default:
+		if client.dropping.CompareAndSwap(false, true) {
+			go manager.drop(client)
+		}
+	}
+}
+
+// stopping reports whether Stop has been called
+func (manager *Manager) stopping() bool {
+	select {
+	case <-manager.done:
+		return true
+	default:
+		return false
+	}
+}
+
 func (m *Manager) Run(ctx *gin.Context) {
 	ticketID := ctx.Query("ticket")
 	if ticketID == "" {
@@ -138,42 +260,126 @@ func (m *Manager) Run(ctx *gin.Context) {
 		return
 	}
 
+	if m.stopping() {
+		ctx.JSON(http.StatusServiceUnavailable, gin.H{"error": "Server is restarting"})
+		return
+	}
+
 	conn, err := upgrader.Upgrade(ctx.Writer, ctx.Request, nil)
 	if err != nil {
 		log.Printf("WebSocket upgrade failed: %v", err)
 		return
 	}
 
-	roomID := ctx.Param("id")
-	if roomID == "" {
-		log.Printf("WebSocket connection missing auction ID parameter")
-		conn.Close()
-		return
+	// A connection opened on an auction's URL starts in that room; one opened on /ws starts with
+	// only the user's own notifications and subscribes to rooms as it goes. A reconnect that
+	// passes the last epoch and seq it saw joins the room once registered, replaying what it
+	// missed first
+	rooms := make(map[string]bool)
+	var resume *pendingResume
+	if roomID := ctx.Param("id"); roomID != "" {
+		if from, ok := resumeFromQuery(ctx); ok {
+			resume = &pendingResume{roomID: roomID, from: from}
+		} else {
+			rooms[roomID] = true
+		}
 	}
 
 	// Create new client for this connection
 	client := &Client{
 		ConnID:     uuid.New().String(),
 		UserID:     userID,
-		RoomID:     roomID,
+		rooms:      rooms,
+		resume:     resume,
 		connection: conn,
 		manager:    m,
 		send:       make(chan []byte, 256), // Buffered to prevent blocking
 	}
 
-	// Register the client with the manager
-	m.register <- client
+	// Register the client with the manager, which starts its pumps
+	select {
+	case m.register <- client:
+	case <-m.done:
+		conn.WriteControl(websocket.CloseMessage, restartMessage, time.Now().Add(writeWait))
+		conn.Close()
+	}
+}
 
-	go client.writePump()
-	go client.readPump()
+// resumeFromQuery reads the resume_epoch and last_seq query params
+func resumeFromQuery(ctx *gin.Context) (*resumePoint, bool) {
+	epoch := ctx.Query("resume_epoch")
+	if epoch == "" {
+		return nil, false
+	}
+	lastSeq, err := strconv.ParseUint(ctx.Query("last_seq"), 10, 64)
+	if err != nil {
+		return nil, false
+	}
+	return &resumePoint{Epoch: epoch, LastSeq: lastSeq}, true
 }
 
 func (manager *Manager) Broadcast(message []byte) {
-	manager.broadcast <- Message{RoomID: "", Data: message}
+	manager.publish(Message{RoomID: "", Data: message})
 }
 
-func (manager *Manager) broadcastToRoom(roomID string, message []byte) {
-	manager.broadcast <- Message{RoomID: roomID, Data: message}
+// sendToClient queues data for one client. The membership check under mu keeps it from
+// sending on a channel the run loop has closed
+func (manager *Manager) sendToClient(client *Client, data []byte) {
+	manager.mu.RLock()
+	defer manager.mu.RUnlock()
+	if manager.clients[client] {
+		manager.deliver(client, data)
+	}
+}
+
+// subscribe adds a client to a room. It reports false when the client is already at its room
+// limit; subscribing to a room it is already in is a no-op
+func (manager *Manager) subscribe(client *Client, roomID string) bool {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	if client.rooms[roomID] {
+		return true
+	}
+	if len(client.rooms) >= maxRoomsPerClient {
+		return false
+	}
+	client.rooms[roomID] = true
+	// A client that has already gone is only tracked on itself
+	if !client.closed {
+		manager.rooms.add(roomID, client)
+	}
+	return true
+}
+
+// unsubscribe removes a client from a room and reports whether it was in it
+func (manager *Manager) unsubscribe(client *Client, roomID string) bool {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	if !client.rooms[roomID] {
+		return false
+	}
+	delete(client.rooms, roomID)
+	if !client.closed {
+		manager.rooms.remove(roomID, client)
+	}
+	return true
+}
+
+func (manager *Manager) isSubscribed(client *Client, roomID string) bool {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	return client.rooms[roomID]
+}
+
+// roomsOf lists the rooms a client is subscribed to
+func (manager *Manager) roomsOf(client *Client) []string {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	rooms := make([]string, 0, len(client.rooms))
+	for roomID := range client.rooms {
+		rooms = append(rooms, roomID)
+	}
+	return rooms
 }
 
 func (manager *Manager) GetClientCount() int {
@@ -183,15 +389,7 @@ func (manager *Manager) GetClientCount() int {
 }
 
 func (manager *Manager) GetRoomClientCount(roomID string) int {
-	manager.mu.RLock()
-	defer manager.mu.RUnlock()
-	count := 0
-	for client := range manager.clients {
-		if client.RoomID == roomID {
-			count++
-		}
-	}
-	return count
+	return manager.rooms.count(roomID)
 }
 
 func (manager *Manager) SendNotificationToUser(userID string, notification Notification) {
@@ -201,19 +399,9 @@ func (manager *Manager) SendNotificationToUser(userID string, notification Notif
 		return
 	}
 
-	manager.mu.RLock()
-	defer manager.mu.RUnlock()
-	for client := range manager.clients {
-		if client.UserID == userID {
-			select {
-			case client.send <- data:
-			default:
-				go func(c *Client) {
-					manager.unregister <- c
-				}(client)
-			}
-		}
-	}
+	manager.users.each(userID, func(client *Client) {
+		manager.deliver(client, data)
+	})
 }
 
 func (manager *Manager) BroadcastNotificationToRoom(roomID string, notification Notification) {
@@ -223,22 +411,34 @@ func (manager *Manager) BroadcastNotificationToRoom(roomID string, notification
 		return
 	}
 
-	manager.broadcast <- Message{RoomID: roomID, Data: data}
+	manager.publish(Message{RoomID: roomID, Data: data})
 }
 
-// Stop gracefully shuts down the manager by closing all channels
-func (manager *Manager) Stop() {
-	manager.mu.Lock()
-	defer manager.mu.Unlock()
+// Stop refuses new connections and closes every open one with a "server restarting" close
+// frame. It waits for the close frames to be written until ctx is done, then drops whatever
+// connections are left. Calling it again just waits again
+func (manager *Manager) Stop(ctx context.Context) error {
+	manager.stopOnce.Do(func() { close(manager.done) })
 
-	// Close all client connections
-	for client := range manager.clients {
-		close(client.send)
-		client.connection.Close()
+	select {
+	case <-manager.stopped:
+	case <-ctx.Done():
+		return ctx.Err()
 	}
 
-	// Close manager channels
-	close(manager.register)
-	close(manager.unregister)
-	close(manager.broadcast)
+	flushed := make(chan struct{})
+	go func() {
+		manager.pumps.Wait()
+		close(flushed)
+	}()
+
+	select {
+	case <-flushed:
+		return nil
+	case <-ctx.Done():
+		for _, client := range manager.closed {
+			client.connection.Close()
+		}
+		return ctx.Err()
+	}
 }
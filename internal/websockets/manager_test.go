@@ -0,0 +1,304 @@
+package websockets
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+)
+
+// newTestClient builds a client with no connection and adds it straight to the manager's
+// indexes, the way the run loop does on register, without starting any pumps
+func newTestClient(m *Manager, userID string, buffer int, rooms ...string) *Client {
+	client := &Client{
+		ConnID:  uuid.NewString(),
+		UserID:  userID,
+		rooms:   make(map[string]bool),
+		manager: m,
+		send:    make(chan []byte, buffer),
+	}
+	for _, roomID := range rooms {
+		client.rooms[roomID] = true
+	}
+	m.mu.Lock()
+	m.addClient(client)
+	m.mu.Unlock()
+	return client
+}
+
+// hammer runs fn in a loop on its own goroutine until stop is closed, failing the test instead
+// of crashing it if fn panics
+func hammer(t *testing.T, wg *sync.WaitGroup, stop <-chan struct{}, fn func(i int)) {
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer func() {
+			if r := recover(); r != nil {
+				t.Errorf("panic: %v", r)
+			}
+		}()
+		for i := 0; ; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			fn(i)
+		}
+	}()
+}
+
+// TestStopRacesDropAndSlowConsumers stops the manager while clients are being dropped directly
+// and for falling behind, and while messages are still being fanned out to them. Any send on a
+// closed channel or second close panics
+func TestStopRacesDropAndSlowConsumers(t *testing.T) {
+	m := NewManager()
+	m.Start()
+
+	rooms := []string{uuid.NewString(), uuid.NewString(), uuid.NewString()}
+	var clients, slow []*Client
+	var drained sync.WaitGroup
+	for i := range 200 {
+		userID := fmt.Sprintf("user-%d", i%20)
+		roomID := rooms[i%len(rooms)]
+		if i%2 == 0 {
+			// A one message buffer nobody drains makes the client a slow consumer almost at once
+			client := newTestClient(m, userID, 1, roomID)
+			slow = append(slow, client)
+			clients = append(clients, client)
+			continue
+		}
+		// The rest keep up, draining like a write pump until Stop closes them
+		client := newTestClient(m, userID, 256, roomID)
+		clients = append(clients, client)
+		drained.Add(1)
+		go func() {
+			defer drained.Done()
+			for range client.send {
+			}
+		}()
+	}
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	notification := NewNotification(NotifBidPlaced, "New bid", map[string]any{"amount": "10"})
+
+	for _, roomID := range rooms {
+		hammer(t, &wg, stop, func(i int) {
+			m.BroadcastNotificationToRoom(roomID, notification)
+		})
+	}
+	hammer(t, &wg, stop, func(i int) {
+		m.SendNotificationToUser(fmt.Sprintf("user-%d", i%20), notification)
+	})
+	hammer(t, &wg, stop, func(i int) {
+		m.Broadcast([]byte(`{"v":1}`))
+	})
+	hammer(t, &wg, stop, func(i int) {
+		client := clients[i%len(clients)]
+		m.sendToClient(client, []byte(`{"v":1}`))
+		m.subscribe(client, rooms[(i+1)%len(rooms)])
+		m.unsubscribe(client, rooms[(i+1)%len(rooms)])
+	})
+	for range 4 {
+		hammer(t, &wg, stop, func(i int) {
+			// Dropping the same client more than once must be harmless. Only the slow clients
+			// are dropped, so the others are still registered when Stop runs
+			m.drop(slow[i%len(slow)])
+		})
+	}
+
+	time.Sleep(20 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	errs := make(chan error, 2)
+	for range 2 {
+		go func() { errs <- m.Stop(ctx) }()
+	}
+	for range 2 {
+		if err := <-errs; err != nil {
+			t.Fatalf("Stop: %v", err)
+		}
+	}
+
+	// Keep publishing for a moment after the manager has stopped
+	time.Sleep(10 * time.Millisecond)
+	close(stop)
+	wg.Wait()
+
+	if n := m.GetClientCount(); n != 0 {
+		t.Errorf("expected no clients after Stop, got %d", n)
+	}
+	for _, roomID := range rooms {
+		if n := m.GetRoomClientCount(roomID); n != 0 {
+			t.Errorf("expected room %s to be empty after Stop, got %d", roomID, n)
+		}
+	}
+	drained.Wait()
+	for _, client := range slow {
+		for range client.send {
+		}
+	}
+	for _, client := range clients {
+		client.mu.Lock()
+		closed := client.closed
+		client.mu.Unlock()
+		if !closed {
+			t.Errorf("client %s was not marked closed", client.ConnID)
+		}
+	}
+}
+
+// TestStopClosesLiveConnections stops the manager while real connections are closing on their
+// own and a room is being flooded, and checks every connection still open gets a close frame
+func TestStopClosesLiveConnections(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	m := NewManager()
+	m.Start()
+
+	router := gin.New()
+	router.GET("/auctions/:id/ws", m.Run)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	roomID := uuid.NewString()
+	base := "ws" + strings.TrimPrefix(server.URL, "http") + "/auctions/" + roomID + "/ws?ticket="
+
+	var conns []*websocket.Conn
+	for i := range 40 {
+		conn, _, err := websocket.DefaultDialer.Dial(base+m.CreateTicket(fmt.Sprintf("user-%d", i)), nil)
+		if err != nil {
+			t.Fatalf("dial: %v", err)
+		}
+		defer conn.Close()
+		conns = append(conns, conn)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for m.GetRoomClientCount(roomID) < len(conns) {
+		if time.Now().After(deadline) {
+			t.Fatalf("only %d of %d clients registered", m.GetRoomClientCount(roomID), len(conns))
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	notification := NewNotification(NotifBidPlaced, "New bid", map[string]any{"amount": "10"})
+	hammer(t, &wg, stop, func(i int) {
+		m.BroadcastNotificationToRoom(roomID, notification)
+	})
+
+	// Half the clients hang up while Stop runs, so their read pumps drop them concurrently
+	for _, conn := range conns[:len(conns)/2] {
+		go conn.Close()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := m.Stop(ctx); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	close(stop)
+	wg.Wait()
+
+	// The rest never read, so some will have been dropped as slow consumers and the others sent
+	// the restart frame; either way the server has closed them
+	for _, conn := range conns[len(conns)/2:] {
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				if _, ok := err.(*websocket.CloseError); !ok {
+					t.Errorf("expected a close frame, got %v", err)
+				}
+				break
+			}
+		}
+	}
+
+	if n := m.GetClientCount(); n != 0 {
+		t.Errorf("expected no clients after Stop, got %d", n)
+	}
+}
+
+const (
+	benchClients = 10000
+	// benchDrainEvery is how many fan-outs run between drains. It stays under the send buffer
+	// so no client is ever dropped as a slow consumer mid-benchmark
+	benchDrainEvery = 128
+)
+
+func drainAll(clients []*Client) {
+	for _, client := range clients {
+		for len(client.send) > 0 {
+			<-client.send
+		}
+	}
+}
+
+// BenchmarkPublishToRoom fans one notification out to a room of 10k connections. ns/op is the
+// latency of a whole fan-out
+func BenchmarkPublishToRoom(b *testing.B) {
+	m := NewManager()
+	roomID := uuid.NewString()
+	clients := make([]*Client, 0, benchClients)
+	for i := range benchClients {
+		clients = append(clients, newTestClient(m, fmt.Sprintf("user-%d", i), 256, roomID))
+	}
+	notification := NewNotification(NotifBidPlaced, "New bid", map[string]any{"amount": "10"})
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := range b.N {
+		m.BroadcastNotificationToRoom(roomID, notification)
+		if i%benchDrainEvery == benchDrainEvery-1 {
+			b.StopTimer()
+			drainAll(clients)
+			b.StartTimer()
+		}
+	}
+	b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(b.N*benchClients), "ns/client")
+	if n := m.GetRoomClientCount(roomID); n != benchClients {
+		b.Fatalf("%d clients were dropped during the benchmark", benchClients-n)
+	}
+}
+
+// BenchmarkSendNotificationToUser sends a notification to one user's connections out of 10k
+// open ones, two per user, so it measures the lookup as much as the fan-out. ns/op is the
+// latency of one user's fan-out
+func BenchmarkSendNotificationToUser(b *testing.B) {
+	m := NewManager()
+	users := benchClients / 2
+	clients := make([]*Client, 0, benchClients)
+	for i := range benchClients {
+		clients = append(clients, newTestClient(m, fmt.Sprintf("user-%d", i%users), 256))
+	}
+	userIDs := make([]string, users)
+	for i := range userIDs {
+		userIDs[i] = fmt.Sprintf("user-%d", i)
+	}
+	notification := NewNotification(NotifBidOutbid, "You have been outbid", map[string]any{"amount": "10"})
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := range b.N {
+		m.SendNotificationToUser(userIDs[i%users], notification)
+		// Each user is sent to once per pass over userIDs
+		if i%(users*benchDrainEvery) == users*benchDrainEvery-1 {
+			b.StopTimer()
+			drainAll(clients)
+			b.StartTimer()
+		}
+	}
+	if n := m.GetClientCount(); n != benchClients {
+		b.Fatalf("%d clients were dropped during the benchmark", benchClients-n)
+	}
+}
@@ -8,15 +8,23 @@ import (
 type NotificationType string
 
 const (
-	NotifBidPlaced       NotificationType = "BID_PLACED"
-	NotifBidOutbid       NotificationType = "BID_OUTBID"
-	NotifAuctionStarted  NotificationType = "AUCTION_STARTED"
-	NotifAuctionEnding   NotificationType = "AUCTION_ENDING_SOON"
-	NotifAuctionEnded    NotificationType = "AUCTION_ENDED"
-	NotifAuctionWon      NotificationType = "AUCTION_WON"
-	NotifAuctionLost     NotificationType = "AUCTION_LOST"
-	NotifPaymentReminder NotificationType = "PAYMENT_REMINDER"
-	NotifSystemMessage   NotificationType = "SYSTEM_MESSAGE"
+	NotifBidPlaced        NotificationType = "BID_PLACED"
+	NotifBidOutbid        NotificationType = "BID_OUTBID"
+	NotifAuctionStarted   NotificationType = "AUCTION_STARTED"
+	NotifAuctionEnding    NotificationType = "AUCTION_ENDING_SOON"
+	NotifAuctionEnded     NotificationType = "AUCTION_ENDED"
+	NotifAuctionExtended  NotificationType = "AUCTION_EXTENDED"
+	NotifAuctionBoughtNow NotificationType = "AUCTION_BOUGHT_NOW"
+	NotifAuctionCancelled NotificationType = "AUCTION_CANCELLED"
+	NotifAuctionRelisted  NotificationType = "AUCTION_RELISTED"
+	NotifPriceDrop        NotificationType = "PRICE_DROP"
+	NotifReserveMet       NotificationType = "RESERVE_MET"
+	NotifAuctionWon       NotificationType = "AUCTION_WON"
+	NotifAuctionLost      NotificationType = "AUCTION_LOST"
+	NotifPaymentReminder  NotificationType = "PAYMENT_REMINDER"
+	NotifPaymentExpired   NotificationType = "PAYMENT_EXPIRED"
+	NotifSecondChance     NotificationType = "SECOND_CHANCE_OFFER"
+	NotifSystemMessage    NotificationType = "SYSTEM_MESSAGE"
 )
 
 type Notification struct {
@@ -39,8 +47,9 @@ func NewNotification(notifType NotificationType, message string, data map[string
 
 // ToMessage converts a notification into a raw JSON message for the WebSocket
 func (n Notification) ToMessage() ([]byte, error) {
-	// Standard format: { "type": "notification", "payload": { ...notification } }
+	// Standard format: { "v": 1, "type": "notification", "payload": { ...notification } }
 	msg := map[string]interface{}{
+		"v":       ProtocolVersion,
 		"type":    "notification",
 		"payload": n,
 	}
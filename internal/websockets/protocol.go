@@ -0,0 +1,269 @@
+package websockets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ProtocolVersion is the envelope version spoken in both directions. Commands with any other
+// version are rejected so old clients fail loudly instead of half working
+const ProtocolVersion = 1
+
+// commandTimeout bounds how long a single command may take
+const commandTimeout = 10 * time.Second
+
+type CommandType string
+
+const (
+	CmdSubscribe   CommandType = "subscribe"
+	CmdUnsubscribe CommandType = "unsubscribe"
+	CmdPlaceBid    CommandType = "place_bid"
+	CmdPing        CommandType = "ping"
+	CmdTyping      CommandType = "typing"
+	CmdResume      CommandType = "resume"
+)
+
+// Command is a message from a client. ID is chosen by the client and echoed on the reply so it
+// can match the two up
+type Command struct {
+	Version int             `json:"v"`
+	ID      string          `json:"id"`
+	Type    CommandType     `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// Reply types sent by the server
+const (
+	ReplyAck   = "ack"
+	ReplyError = "error"
+	ReplyEvent = "event"
+)
+
+// Reply is a message from the server that isn't a notification: the answer to a command, or
+// an event another client caused
+type Reply struct {
+	Version int           `json:"v"`
+	ID      string        `json:"id,omitempty"`
+	Type    string        `json:"type"`
+	Command CommandType   `json:"command,omitempty"`
+	Payload any           `json:"payload,omitempty"`
+	Error   *CommandError `json:"error,omitempty"`
+}
+
+// Error codes sent back to clients
+const (
+	ErrCodeInvalidMessage = "invalid_message"
+	ErrCodeUnsupported    = "unsupported_version"
+	ErrCodeUnknownCommand = "unknown_command"
+	ErrCodeInvalidPayload = "invalid_payload"
+	ErrCodeNotSubscribed  = "not_subscribed"
+	ErrCodeTooManyRooms   = "too_many_rooms"
+	ErrCodeInternal       = "internal_error"
+	ErrCodeUnauthorized   = "unauthorized"
+	ErrCodeBidRejected    = "bid_rejected"
+)
+
+// CommandError is an error a command handler wants the client to see. Any other error is
+// reported as internal_error without its details
+type CommandError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *CommandError) Error() string {
+	return e.Code + ": " + e.Message
+}
+
+func NewCommandError(code, message string) *CommandError {
+	return &CommandError{Code: code, Message: message}
+}
+
+// CommandHandler runs a command for client and returns the ack payload
+type CommandHandler func(ctx context.Context, client *Client, cmd Command) (any, error)
+
+// HandleCommand registers the handler for a command type, replacing any existing one. Handlers
+// must be registered before the manager starts taking connections
+func (manager *Manager) HandleCommand(cmdType CommandType, handler CommandHandler) {
+	manager.handlers[cmdType] = handler
+}
+
+func (manager *Manager) registerBuiltinCommands() {
+	manager.HandleCommand(CmdPing, handlePing)
+	manager.HandleCommand(CmdSubscribe, manager.handleSubscribe)
+	manager.HandleCommand(CmdUnsubscribe, manager.handleUnsubscribe)
+	manager.HandleCommand(CmdTyping, manager.handleTyping)
+	manager.HandleCommand(CmdResume, manager.handleResume)
+}
+
+// dispatch parses a raw client message and runs the matching handler. Nothing a client sends
+// is ever passed on as is; anything other clients see is built by a handler
+func (c *Client) dispatch(raw []byte) {
+	var cmd Command
+	if err := json.Unmarshal(raw, &cmd); err != nil || cmd.Type == "" {
+		c.replyError("", "", NewCommandError(ErrCodeInvalidMessage, "message must be a JSON command envelope"))
+		return
+	}
+	if cmd.Version != ProtocolVersion {
+		c.replyError(cmd.ID, cmd.Type, NewCommandError(ErrCodeUnsupported, "unsupported protocol version"))
+		return
+	}
+
+	handler, ok := c.manager.handlers[cmd.Type]
+	if !ok {
+		c.replyError(cmd.ID, cmd.Type, NewCommandError(ErrCodeUnknownCommand, "unknown command"))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+
+	payload, err := handler(ctx, c, cmd)
+	if err != nil {
+		c.replyError(cmd.ID, cmd.Type, err)
+		return
+	}
+	c.reply(Reply{Version: ProtocolVersion, ID: cmd.ID, Type: ReplyAck, Command: cmd.Type, Payload: payload})
+}
+
+func (c *Client) replyError(id string, cmdType CommandType, err error) {
+	var cmdErr *CommandError
+	if !errors.As(err, &cmdErr) {
+		log.Printf("[WEBSOCKET] %s command failed for client %s: %v", cmdType, c.ConnID, err)
+		cmdErr = NewCommandError(ErrCodeInternal, "something went wrong, please try again")
+	}
+	c.reply(Reply{Version: ProtocolVersion, ID: id, Type: ReplyError, Command: cmdType, Error: cmdErr})
+}
+
+func (c *Client) reply(reply Reply) {
+	data, err := json.Marshal(reply)
+	if err != nil {
+		log.Printf("[WEBSOCKET] failed to marshal reply: %v", err)
+		return
+	}
+	c.manager.sendToClient(c, data)
+}
+
+// DecodePayload unmarshals the command's payload into v. The error is ready to send back
+func (cmd Command) DecodePayload(v any) error {
+	if len(cmd.Payload) == 0 {
+		return NewCommandError(ErrCodeInvalidPayload, "payload is required")
+	}
+	if err := json.Unmarshal(cmd.Payload, v); err != nil {
+		return NewCommandError(ErrCodeInvalidPayload, "payload is malformed")
+	}
+	return nil
+}
+
+type roomPayload struct {
+	RoomID string `json:"room_id"`
+}
+
+// roomFrom reads and checks the room a command is about
+func roomFrom(cmd Command) (string, error) {
+	var payload roomPayload
+	if err := cmd.DecodePayload(&payload); err != nil {
+		return "", err
+	}
+	if _, err := uuid.Parse(payload.RoomID); err != nil {
+		return "", NewCommandError(ErrCodeInvalidPayload, "room_id must be a valid id")
+	}
+	return payload.RoomID, nil
+}
+
+func handlePing(ctx context.Context, client *Client, cmd Command) (any, error) {
+	return map[string]any{"server_time": time.Now()}, nil
+}
+
+// handleSubscribe adds an auction room to the connection. The user's own notifications always
+// arrive on every connection they have open, whatever it is subscribed to
+func (manager *Manager) handleSubscribe(ctx context.Context, client *Client, cmd Command) (any, error) {
+	roomID, err := roomFrom(cmd)
+	if err != nil {
+		return nil, err
+	}
+	return manager.joinRoom(client, roomID, nil)
+}
+
+type resumePayload struct {
+	RoomID  string `json:"room_id"`
+	Epoch   string `json:"epoch"`
+	LastSeq uint64 `json:"last_seq"`
+}
+
+// handleResume rejoins a room after a reconnect. Messages after last_seq are replayed before
+// the ack; if they are no longer kept, or the epoch has changed, the ack says a full refresh is
+// required instead
+func (manager *Manager) handleResume(ctx context.Context, client *Client, cmd Command) (any, error) {
+	var payload resumePayload
+	if err := cmd.DecodePayload(&payload); err != nil {
+		return nil, err
+	}
+	if _, err := uuid.Parse(payload.RoomID); err != nil {
+		return nil, NewCommandError(ErrCodeInvalidPayload, "room_id must be a valid id")
+	}
+	if payload.Epoch == "" {
+		return nil, NewCommandError(ErrCodeInvalidPayload, "epoch is required")
+	}
+	return manager.joinRoom(client, payload.RoomID, &resumePoint{Epoch: payload.Epoch, LastSeq: payload.LastSeq})
+}
+
+func (manager *Manager) joinRoom(client *Client, roomID string, from *resumePoint) (any, error) {
+	pos, ok := manager.join(client, roomID, from)
+	if !ok {
+		return nil, NewCommandError(ErrCodeTooManyRooms, fmt.Sprintf("a connection can follow at most %d rooms", maxRoomsPerClient))
+	}
+	pos.Rooms = manager.roomsOf(client)
+	return pos, nil
+}
+
+// resumeRoom handles a resume asked for in the connection URL. There is no command to ack, so
+// the outcome is sent as an event
+func (c *Client) resumeRoom(roomID string, from *resumePoint) {
+	payload, err := c.manager.joinRoom(c, roomID, from)
+	if err != nil {
+		c.replyError("", CmdResume, err)
+		return
+	}
+	c.reply(Reply{Version: ProtocolVersion, Type: ReplyEvent, Command: CmdResume, Payload: payload})
+}
+
+// handleUnsubscribe removes an auction room from the connection
+func (manager *Manager) handleUnsubscribe(ctx context.Context, client *Client, cmd Command) (any, error) {
+	roomID, err := roomFrom(cmd)
+	if err != nil {
+		return nil, err
+	}
+	if !manager.unsubscribe(client, roomID) {
+		return nil, NewCommandError(ErrCodeNotSubscribed, "not subscribed to this room")
+	}
+	return map[string]any{"room_id": roomID, "rooms": manager.roomsOf(client)}, nil
+}
+
+// handleTyping tells everyone in one of the connection's rooms that the user is typing
+func (manager *Manager) handleTyping(ctx context.Context, client *Client, cmd Command) (any, error) {
+	roomID, err := roomFrom(cmd)
+	if err != nil {
+		return nil, err
+	}
+	if !manager.isSubscribed(client, roomID) {
+		return nil, NewCommandError(ErrCodeNotSubscribed, "not subscribed to this room")
+	}
+
+	data, err := json.Marshal(Reply{
+		Version: ProtocolVersion,
+		Type:    ReplyEvent,
+		Command: CmdTyping,
+		Payload: map[string]any{"room_id": roomID, "user_id": client.UserID},
+	})
+	if err != nil {
+		return nil, err
+	}
+	manager.publish(Message{RoomID: roomID, Data: data})
+	return nil, nil
+}